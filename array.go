@@ -12,39 +12,211 @@ type Array struct {
 	array      []interface{}
 	maxSize    int
 	atCapacity bool
+	seqs       []uint64 // sequence numbers of the elements of array
+	next       uint64   // sequence number of the last element pushed
+	codec      Codec
+	clock      Clock
+	transforms []Transform
+	budget     *budgetMember
+	views      []*View
+	sampler    *sampler
+	coalesce   func(a, b interface{}) bool
+
+	foldInit interface{}
+	foldFn   func(acc, evicted interface{}) interface{}
+	folded   interface{}
+}
+
+// Option configures an Array.
+type Option func(*Array)
+
+// WithCodec sets the Codec used to serialize the elements of the array;
+// the default is DefaultCodec.
+func WithCodec(c Codec) Option {
+	return func(a *Array) {
+		a.codec = c
+	}
 }
 
 // New returns a new Array; maxSize MUST be a positive number.
-func New(maxSize int) *Array {
+func New(maxSize int, opts ...Option) *Array {
 	if maxSize < 0 {
 		panic("fixedarr.New: maxSize cannot be less than 0")
 	}
-	return &Array{
+	a := &Array{
 		mu:      &sync.RWMutex{},
 		array:   make([]interface{}, 0),
 		maxSize: maxSize,
+		codec:   DefaultCodec,
+		clock:   SystemClock,
 	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
 }
 
 // Push pushes (appends) an element to the array; if the array has reached
 // its limit capacity, the oldest element will be removed.
 func (a *Array) Push(el interface{}) {
+	a.add(el)
+}
+
+// PushMany pushes the elements in order, as a single operation: no other
+// goroutine sees the array with only some of them pushed.
+func (a *Array) PushMany(els ...interface{}) {
+	var c change
+	a.mu.Lock()
+	for _, el := range els {
+		a.admit(el, &c)
+	}
+	budget := a.budget
+	a.mu.Unlock()
+
+	c.settle(budget)
+}
+
+// add pushes el, and returns a Handle to the element holding it.
+func (a *Array) add(el interface{}) Handle {
+	var c change
 	a.mu.Lock()
-	defer a.mu.Unlock()
+	h := a.admit(el, &c)
+	budget := a.budget
+	a.mu.Unlock()
 
+	c.settle(budget)
+	return h
+}
+
+// change records the elements pushed and evicted while a.mu is held, so
+// that the Budget of the array can be adjusted once it is released.
+type change struct {
+	pushed, evicted []interface{}
+}
+
+// settle adjusts budget, if any, by the weight of the change; the array
+// lock MUST NOT be held.
+func (c *change) settle(budget *budgetMember) {
+	if budget != nil && len(c.pushed)+len(c.evicted) > 0 {
+		budget.adjust(budget.weighAll(c.pushed) - budget.weighAll(c.evicted))
+	}
+}
+
+// admit pushes el unless it is sampled out or coalesced, records the change
+// in c if the array has a Budget, and returns a Handle to the element
+// holding el; a.mu MUST be held.
+func (a *Array) admit(el interface{}, c *change) Handle {
+	if a.sampler != nil && !a.sampler.admit(a.clock.Now()) {
+		return 0
+	}
+	stored := el
+	if a.coalesce != nil {
+		var ok bool
+		if stored, ok = a.coalesced(el); !ok {
+			return Handle(a.seqs[len(a.seqs)-1])
+		}
+	}
+	evicted, ok := a.push(stored)
+	for _, v := range a.views {
+		v.offer(el)
+	}
+	if a.budget != nil {
+		c.pushed = append(c.pushed, stored)
+		if ok {
+			c.evicted = append(c.evicted, evicted)
+		}
+	}
+	return Handle(a.next)
+}
+
+// push appends el, and returns the element removed to make room for it,
+// if any; a.mu MUST be held.
+func (a *Array) push(el interface{}) (interface{}, bool) {
+	var evicted interface{}
+	var ok bool
 	if a.atCapacity || len(a.array) >= a.maxSize && len(a.array) > 0 {
 
 		if !a.atCapacity {
 			a.atCapacity = true
 		}
-		i := 0
-		copy(a.array[i:], a.array[i+1:])
-		a.array[len(a.array)-1] = nil
-		a.array = a.array[:len(a.array)-1]
-
+		evicted, ok = a.evictOldest()
 	}
 
+	a.next++
 	a.array = append(a.array, el)
+	a.seqs = append(a.seqs, a.next)
+	return evicted, ok
+}
+
+// evictOldest removes and returns the oldest element, folding it if the
+// array has a fold; a.mu MUST be held.
+func (a *Array) evictOldest() (interface{}, bool) {
+	el, ok := a.removeOldest()
+	if ok && a.foldFn != nil {
+		a.folded = a.foldFn(a.folded, el)
+	}
+	return el, ok
+}
+
+// removeOldest removes and returns the oldest element; a.mu MUST be held.
+func (a *Array) removeOldest() (interface{}, bool) {
+	if len(a.array) == 0 {
+		return nil, false
+	}
+	return a.removeAt(0), true
+}
+
+// removeAt removes and returns the element at index i; a.mu MUST be held.
+func (a *Array) removeAt(i int) interface{} {
+	el := a.array[i]
+	copy(a.array[i:], a.array[i+1:])
+	a.array[len(a.array)-1] = nil
+	a.array = a.array[:len(a.array)-1]
+	copy(a.seqs[i:], a.seqs[i+1:])
+	a.seqs = a.seqs[:len(a.seqs)-1]
+	return el
+}
+
+// retain removes the elements for which keep returns false, and returns
+// them; a.mu MUST be held.
+func (a *Array) retain(keep func(el interface{}) bool) []interface{} {
+	var removed []interface{}
+	n := 0
+	for i, el := range a.array {
+		if keep(el) {
+			a.array[n] = el
+			a.seqs[n] = a.seqs[i]
+			n++
+		} else {
+			removed = append(removed, el)
+		}
+	}
+	for i := n; i < len(a.array); i++ {
+		a.array[i] = nil
+	}
+	a.array = a.array[:n]
+	a.seqs = a.seqs[:n]
+	if len(removed) > 0 {
+		a.atCapacity = false
+	}
+	return removed
+}
+
+// Pop removes and returns the oldest element, and false if the array is
+// empty; popped elements are not folded (see WithFold).
+func (a *Array) Pop() (interface{}, bool) {
+	a.mu.Lock()
+	el, ok := a.removeOldest()
+	if ok {
+		a.atCapacity = false
+	}
+	budget := a.budget
+	a.mu.Unlock()
+
+	if ok && budget != nil {
+		budget.adjust(-budget.weigh(el))
+	}
+	return el, ok
 }
 
 // Len returns the current length of the array
@@ -54,9 +226,34 @@ func (a *Array) Len() int {
 
 // Max returns the limit size of the array
 func (a *Array) Max() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
 	return a.maxSize
 }
 
+// SetMax changes the limit size of the array; if the array holds more
+// elements than maxSize, the oldest ones are removed.
+func (a *Array) SetMax(maxSize int) {
+	if maxSize < 0 {
+		panic("fixedarr.SetMax: maxSize cannot be less than 0")
+	}
+	a.mu.Lock()
+	a.maxSize = maxSize
+	a.atCapacity = false
+	var evicted []interface{}
+	for len(a.array) > maxSize {
+		el, _ := a.evictOldest()
+		evicted = append(evicted, el)
+	}
+	budget := a.budget
+	a.mu.Unlock()
+
+	if budget != nil && len(evicted) > 0 {
+		budget.adjust(-budget.weighAll(evicted))
+	}
+}
+
 // Value returns the current array
 func (a *Array) Value() []interface{} {
 	a.mu.RLock()
@@ -67,23 +264,29 @@ func (a *Array) Value() []interface{} {
 
 // Reset resets the array
 func (a *Array) Reset() {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
-
-	a.array = make([]interface{}, 0)
+	a.GetAndReset()
 }
 
 // GetAndReset returns the current array, and resets it
 func (a *Array) GetAndReset() []interface{} {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
-
+	a.mu.Lock()
 	clone := make([]interface{}, 0)
 	for i := range a.array {
 		clone = append(clone, a.array[i])
 	}
 
 	a.array = make([]interface{}, 0)
+	a.seqs = nil
+	a.atCapacity = false
+	a.folded = a.foldInit
+	for _, v := range a.views {
+		v.arr.Reset()
+	}
+	budget := a.budget
+	a.mu.Unlock()
 
+	if budget != nil {
+		budget.adjust(-budget.weighAll(clone))
+	}
 	return clone
 }
@@ -0,0 +1,49 @@
+package fixedarr_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gagliardetto/fixedarr"
+	"github.com/gagliardetto/fixedarr/fixedarrtest"
+)
+
+func newArray(maxSize int) fixedarrtest.Array {
+	return fixedarr.New(maxSize)
+}
+
+func FuzzArray(f *testing.F) {
+	fixedarrtest.Fuzz(f, newArray)
+}
+
+func TestArray(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for maxSize := 1; maxSize <= 16; maxSize++ {
+		for i := 0; i < 20; i++ {
+			fixedarrtest.Run(t, newArray, maxSize, fixedarrtest.RandomOps(r, 200))
+		}
+	}
+}
+
+func TestArraySetMax(t *testing.T) {
+	fixedarrtest.Run(t, newArray, 4, []fixedarrtest.Op{
+		{Kind: fixedarrtest.OpPushMany, Values: []int{0, 1, 2, 3, 4}},
+		{Kind: fixedarrtest.OpSetMax, N: 2},
+		{Kind: fixedarrtest.OpPush, Values: []int{5}},
+		{Kind: fixedarrtest.OpSetMax, N: 3},
+		{Kind: fixedarrtest.OpPush, Values: []int{6}},
+		{Kind: fixedarrtest.OpPush, Values: []int{7}},
+		{Kind: fixedarrtest.OpPop},
+		{Kind: fixedarrtest.OpSetMax, N: 1},
+	})
+}
+
+func TestArrayResetAtCapacity(t *testing.T) {
+	a := fixedarr.New(2)
+	a.PushMany(1, 2, 3)
+	a.Reset()
+	a.Push(4)
+	if got := a.Value(); len(got) != 1 || got[0] != 4 {
+		t.Fatalf("Value() = %v; want [4]", got)
+	}
+}
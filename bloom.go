@@ -0,0 +1,147 @@
+package fixedarr
+
+import (
+	"hash/maphash"
+	"math"
+	"sync"
+	"time"
+)
+
+// BloomConfig configures an AgingBloom.
+type BloomConfig struct {
+	// Window is how long items are remembered.
+	Window time.Duration
+	// Generations is the number of filters the window is split into; it
+	// defaults to 4. Items are remembered for at least
+	// Window*(Generations-1)/Generations, and at most Window.
+	Generations int
+	// Capacity is the number of distinct items expected per generation.
+	Capacity int
+	// FalsePositiveRate is the target rate of Test reporting an item that
+	// was not added, over the whole window; it defaults to 0.01.
+	FalsePositiveRate float64
+	// Clock measures Window; it defaults to SystemClock.
+	Clock Clock
+}
+
+// AgingBloom is a Bloom filter remembering the items added within a time
+// window, with bounded memory. It is a fixed array of filters, one per
+// generation: items are added to the newest generation, tested against all
+// of them, and every Window/Generations a new generation is pushed,
+// dropping the oldest.
+type AgingBloom struct {
+	mu     *sync.Mutex
+	gens   *periods // of bloomFilter
+	bits   uint64
+	hashes int
+	seed   maphash.Seed
+}
+
+type bloomFilter []uint64
+
+// NewAgingBloom returns a new AgingBloom; cfg.Window and cfg.Capacity MUST
+// be positive.
+func NewAgingBloom(cfg BloomConfig) *AgingBloom {
+	if cfg.Window <= 0 || cfg.Capacity <= 0 {
+		panic("fixedarr.NewAgingBloom: Window and Capacity must be positive")
+	}
+	if cfg.Generations < 1 {
+		cfg.Generations = 4
+	}
+	if cfg.FalsePositiveRate <= 0 || cfg.FalsePositiveRate >= 1 {
+		cfg.FalsePositiveRate = 0.01
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock
+	}
+
+	// A lookup is a false positive if any generation reports one.
+	p := 1 - math.Pow(1-cfg.FalsePositiveRate, 1/float64(cfg.Generations))
+	n := float64(cfg.Capacity)
+	bits := math.Ceil(-n * math.Log(p) / (math.Ln2 * math.Ln2))
+	hashes := int(math.Round(bits / n * math.Ln2))
+	if hashes < 1 {
+		hashes = 1
+	}
+
+	b := &AgingBloom{
+		mu:     &sync.Mutex{},
+		bits:   uint64(bits),
+		hashes: hashes,
+		seed:   maphash.MakeSeed(),
+	}
+	period := cfg.Window / time.Duration(cfg.Generations)
+	b.gens = newPeriods(cfg.Generations, period, cfg.Clock, func() interface{} { return b.newFilter() })
+	for i := 0; i < cfg.Generations; i++ {
+		b.gens.Push(b.newFilter())
+	}
+	return b
+}
+
+func (b *AgingBloom) newFilter() bloomFilter {
+	return make(bloomFilter, (b.bits+63)/64)
+}
+
+// locations calls fn with the word index and bit of every location of item,
+// stopping and returning false as soon as fn does.
+func (b *AgingBloom) locations(item []byte, fn func(word int, bit uint64) bool) bool {
+	h := maphash.Bytes(b.seed, item)
+	h1, h2 := h&0xffffffff, h>>32|1
+	for i := 0; i < b.hashes; i++ {
+		loc := (h1 + uint64(i)*h2) % b.bits
+		if !fn(int(loc/64), 1<<(loc%64)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Add records item as seen.
+func (b *AgingBloom) Add(item []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.gens.rotate()
+	b.add(item)
+}
+
+// add records item in the newest generation; b.mu MUST be held.
+func (b *AgingBloom) add(item []byte) {
+	f := b.gens.array[len(b.gens.array)-1].(bloomFilter)
+	b.locations(item, func(word int, bit uint64) bool {
+		f[word] |= bit
+		return true
+	})
+}
+
+// Test reports whether item was (probably) added within the window.
+func (b *AgingBloom) Test(item []byte) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.gens.rotate()
+	return b.test(item)
+}
+
+// test implements Test; b.mu MUST be held.
+func (b *AgingBloom) test(item []byte) bool {
+	for _, el := range b.gens.array {
+		f := el.(bloomFilter)
+		if b.locations(item, func(word int, bit uint64) bool { return f[word]&bit != 0 }) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestAndAdd reports whether item was (probably) added within the window,
+// and records it as seen.
+func (b *AgingBloom) TestAndAdd(item []byte) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.gens.rotate()
+	seen := b.test(item)
+	b.add(item)
+	return seen
+}
@@ -0,0 +1,91 @@
+package fixedarr_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+func TestAgingBloomWindow(t *testing.T) {
+	// Generations of a second: an item is remembered for at least 3s, and
+	// forgotten 4s after the start of its generation, whenever it was added
+	// within it.
+	for _, at := range []time.Duration{0, 999 * time.Millisecond} {
+		clock := fixedarr.NewManualClock(time.Unix(0, 0))
+		b := fixedarr.NewAgingBloom(fixedarr.BloomConfig{
+			Window:   4 * time.Second,
+			Capacity: 100,
+			Clock:    clock,
+		})
+		clock.Advance(at)
+		b.Add([]byte("x"))
+
+		clock.Advance(3 * time.Second)
+		if !b.Test([]byte("x")) {
+			t.Fatalf("added at %v: forgotten after 3s", at)
+		}
+		clock.Advance(4*time.Second - at - 3*time.Second - time.Nanosecond)
+		if !b.Test([]byte("x")) {
+			t.Fatalf("added at %v: forgotten before the window ends", at)
+		}
+		clock.Advance(time.Nanosecond)
+		if b.Test([]byte("x")) {
+			t.Fatalf("added at %v: remembered after the window", at)
+		}
+	}
+}
+
+func TestAgingBloomTestAndAdd(t *testing.T) {
+	b := fixedarr.NewAgingBloom(fixedarr.BloomConfig{
+		Window:   time.Minute,
+		Capacity: 100,
+		Clock:    fixedarr.NewManualClock(time.Unix(0, 0)),
+	})
+	if b.TestAndAdd([]byte("x")) {
+		t.Fatal("TestAndAdd() = true for a new item")
+	}
+	if !b.TestAndAdd([]byte("x")) || !b.Test([]byte("x")) {
+		t.Fatal("item not remembered after TestAndAdd")
+	}
+}
+
+func TestAgingBloomFalsePositiveRate(t *testing.T) {
+	const capacity, generations, trials = 1000, 4, 20000
+	clock := fixedarr.NewManualClock(time.Unix(0, 0))
+	b := fixedarr.NewAgingBloom(fixedarr.BloomConfig{
+		Window:            4 * time.Second,
+		Generations:       generations,
+		Capacity:          capacity,
+		FalsePositiveRate: 0.01,
+		Clock:             clock,
+	})
+	// Fill every generation to its capacity.
+	for g := 0; g < generations; g++ {
+		if g > 0 {
+			clock.Advance(time.Second)
+		}
+		for i := 0; i < capacity; i++ {
+			b.Add([]byte(fmt.Sprintf("in-%d-%d", g, i)))
+		}
+	}
+	for g := 0; g < generations; g++ {
+		for i := 0; i < capacity; i++ {
+			if !b.Test([]byte(fmt.Sprintf("in-%d-%d", g, i))) {
+				t.Fatalf("item %d of generation %d forgotten", i, g)
+			}
+		}
+	}
+
+	var positives int
+	for i := 0; i < trials; i++ {
+		if b.Test([]byte(fmt.Sprintf("out-%d", i))) {
+			positives++
+		}
+	}
+	// The target is 1%; allow twice as much, far above the noise.
+	if rate := float64(positives) / trials; rate > 0.02 {
+		t.Fatalf("false positive rate = %.4f; want about 0.01", rate)
+	}
+}
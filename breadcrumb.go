@@ -0,0 +1,55 @@
+package fixedarr
+
+import (
+	"context"
+	"maps"
+	"time"
+)
+
+// BreadcrumbEntry is a breadcrumb recorded by Breadcrumb.
+type BreadcrumbEntry struct {
+	Time    time.Time
+	Message string
+	Attrs   map[string]interface{}
+}
+
+type breadcrumbsKey struct{}
+
+// WithBreadcrumbs returns a copy of ctx carrying a trail of the last max
+// breadcrumbs; the trail is shared by every context derived from the
+// returned one, including those handed to other goroutines. opts configure
+// the underlying Array (e.g. WithClock, which timestamps breadcrumbs).
+func WithBreadcrumbs(ctx context.Context, max int, opts ...Option) context.Context {
+	return context.WithValue(ctx, breadcrumbsKey{}, New(max, opts...))
+}
+
+// Breadcrumb records msg and a copy of attrs in the trail carried by ctx,
+// so the caller can reuse attrs; it does nothing if ctx carries no trail.
+func Breadcrumb(ctx context.Context, msg string, attrs map[string]interface{}) {
+	a, ok := ctx.Value(breadcrumbsKey{}).(*Array)
+	if !ok {
+		return
+	}
+	a.Push(BreadcrumbEntry{
+		Time:    a.clock.Now(),
+		Message: msg,
+		Attrs:   maps.Clone(attrs),
+	})
+}
+
+// Breadcrumbs returns the trail carried by ctx, oldest first, or nil if
+// ctx carries no trail.
+func Breadcrumbs(ctx context.Context) []BreadcrumbEntry {
+	a, ok := ctx.Value(breadcrumbsKey{}).(*Array)
+	if !ok {
+		return nil
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	crumbs := make([]BreadcrumbEntry, len(a.array))
+	for i, el := range a.array {
+		crumbs[i] = el.(BreadcrumbEntry)
+	}
+	return crumbs
+}
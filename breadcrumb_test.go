@@ -0,0 +1,57 @@
+package fixedarr_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+func TestBreadcrumbs(t *testing.T) {
+	// Without a trail, breadcrumbs are dropped.
+	ctx := context.Background()
+	fixedarr.Breadcrumb(ctx, "dropped", nil)
+	if crumbs := fixedarr.Breadcrumbs(ctx); crumbs != nil {
+		t.Fatalf("Breadcrumbs() = %v without a trail; want nil", crumbs)
+	}
+
+	clock := fixedarr.NewManualClock(time.Unix(0, 0))
+	ctx = fixedarr.WithBreadcrumbs(ctx, 2, fixedarr.WithClock(clock))
+	attrs := map[string]interface{}{"n": 1}
+	for _, msg := range []string{"a", "b", "c"} {
+		clock.Advance(time.Second)
+		fixedarr.Breadcrumb(ctx, msg, attrs)
+		attrs["n"] = attrs["n"].(int) + 1 // the trail keeps a copy
+	}
+	want := []fixedarr.BreadcrumbEntry{
+		{Time: time.Unix(2, 0), Message: "b", Attrs: map[string]interface{}{"n": 2}},
+		{Time: time.Unix(3, 0), Message: "c", Attrs: map[string]interface{}{"n": 3}},
+	}
+	if got := fixedarr.Breadcrumbs(ctx); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Breadcrumbs() = %v; want %v", got, want)
+	}
+}
+
+func TestBreadcrumbsShared(t *testing.T) {
+	type key struct{}
+	ctx := fixedarr.WithBreadcrumbs(context.Background(), 4)
+	derived, cancel := context.WithCancel(context.WithValue(ctx, key{}, "v"))
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fixedarr.Breadcrumb(derived, "from goroutine", nil)
+	}()
+	<-done
+	fixedarr.Breadcrumb(ctx, "from parent", nil)
+
+	for _, c := range []context.Context{ctx, derived} {
+		crumbs := fixedarr.Breadcrumbs(c)
+		if len(crumbs) != 2 || crumbs[0].Message != "from goroutine" || crumbs[1].Message != "from parent" {
+			t.Fatalf("Breadcrumbs() = %v; want both breadcrumbs", crumbs)
+		}
+	}
+}
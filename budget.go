@@ -0,0 +1,172 @@
+package fixedarr
+
+import "sync"
+
+// Budget is a limit on the total weight of the elements held by a group of
+// arrays, on top of their own maxSize. When the total goes over the limit,
+// the oldest elements of the member furthest over its fair share (the limit
+// divided by the number of members, or its minimum if larger) are evicted,
+// never taking a member below its guaranteed minimum.
+type Budget struct {
+	mu      *sync.Mutex
+	limit   int64
+	weight  func(el interface{}) int64
+	used    int64
+	members []*budgetMember
+}
+
+type budgetMember struct {
+	budget *Budget
+	arr    *Array
+	min    int64
+	used   int64
+	left   bool
+}
+
+// NewBudget returns a new Budget of limit; weight returns the weight of an
+// element, and if nil every element weighs 1, so that limit is an element
+// count.
+func NewBudget(limit int64, weight func(el interface{}) int64) *Budget {
+	if limit < 0 {
+		panic("fixedarr.NewBudget: limit cannot be less than 0")
+	}
+	return &Budget{
+		mu:     &sync.Mutex{},
+		limit:  limit,
+		weight: weight,
+	}
+}
+
+// Join adds a to the members of the budget, guaranteeing it at least min
+// weight; an array can be a member of a single budget at a time.
+func (b *Budget) Join(a *Array, min int64) {
+	m := &budgetMember{budget: b, arr: a, min: min}
+
+	// The weight of a is taken with both locks held, so that no Push can
+	// adjust m before it is accounted for; enforce takes a.mu itself.
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	a.mu.Lock()
+	if a.budget != nil {
+		a.mu.Unlock()
+		panic("fixedarr.Budget.Join: array is already a member of a budget")
+	}
+	a.budget = m
+	m.used = m.weighAll(a.array)
+	a.mu.Unlock()
+
+	b.members = append(b.members, m)
+	b.used += m.used
+	b.enforce()
+}
+
+// Leave removes a from the members of the budget.
+func (b *Budget) Leave(a *Array) {
+	a.mu.Lock()
+	m := a.budget
+	if m == nil || m.budget != b {
+		a.mu.Unlock()
+		return
+	}
+	a.budget = nil
+	a.mu.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, other := range b.members {
+		if other == m {
+			b.members = append(b.members[:i], b.members[i+1:]...)
+			break
+		}
+	}
+	m.left = true
+	b.used -= m.used
+}
+
+// Used returns the total weight held by the members.
+func (b *Budget) Used() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.used
+}
+
+// Limit returns the limit of the budget.
+func (b *Budget) Limit() int64 {
+	return b.limit
+}
+
+// enforce evicts elements until the budget is respected, or every member is
+// down to its minimum; b.mu MUST be held.
+func (b *Budget) enforce() {
+	for b.used > b.limit {
+		share := b.limit / int64(len(b.members))
+		var victim *budgetMember
+		var victimOver int64
+		for _, m := range b.members {
+			if m.used <= m.min {
+				continue
+			}
+			fair := share
+			if m.min > fair {
+				fair = m.min
+			}
+			if over := m.used - fair; victim == nil || over > victimOver {
+				victim, victimOver = m, over
+			}
+		}
+		if victim == nil {
+			return
+		}
+
+		a := victim.arr
+		a.mu.Lock()
+		el, ok := a.evictOldest()
+		a.atCapacity = false
+		a.mu.Unlock()
+		if !ok {
+			// The array was emptied concurrently and has not reported it yet.
+			return
+		}
+		w := victim.weigh(el)
+		victim.used -= w
+		b.used -= w
+	}
+}
+
+// weigh returns the weight of el, an element of the array of m, as pushed
+// (see WithCoalesce).
+func (m *budgetMember) weigh(el interface{}) int64 {
+	if m.budget.weight == nil {
+		return 1
+	}
+	return m.budget.weight(m.arr.raw(el))
+}
+
+func (m *budgetMember) weighAll(els []interface{}) int64 {
+	if m.budget.weight == nil {
+		return int64(len(els))
+	}
+	var w int64
+	for _, el := range els {
+		w += m.weigh(el)
+	}
+	return w
+}
+
+// adjust records a change in the weight held by the member, and evicts
+// elements if the budget is exceeded; the array lock MUST NOT be held.
+func (m *budgetMember) adjust(delta int64) {
+	b := m.budget
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if m.left {
+		return
+	}
+	m.used += delta
+	b.used += delta
+	b.enforce()
+}
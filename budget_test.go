@@ -0,0 +1,137 @@
+package fixedarr_test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+func TestBudgetEvictsFurthestOverShare(t *testing.T) {
+	b := fixedarr.NewBudget(6, nil)
+	x, y := fixedarr.New(10), fixedarr.New(10)
+	x.PushMany(1, 2, 3, 4)
+	y.PushMany(1, 2)
+	b.Join(x, 0)
+	b.Join(y, 0)
+	if b.Used() != 6 {
+		t.Fatalf("Used() = %d; want 6", b.Used())
+	}
+
+	// The fair share is 3: x is over it, so x loses its oldest element
+	// even though y received the push.
+	y.Push(3)
+	if want := []interface{}{2, 3, 4}; !reflect.DeepEqual(x.Value(), want) {
+		t.Fatalf("x = %v; want %v", x.Value(), want)
+	}
+	if want := []interface{}{1, 2, 3}; !reflect.DeepEqual(y.Value(), want) {
+		t.Fatalf("y = %v; want %v", y.Value(), want)
+	}
+	if b.Used() != 6 {
+		t.Fatalf("Used() = %d; want 6", b.Used())
+	}
+}
+
+func TestBudgetMinimum(t *testing.T) {
+	b := fixedarr.NewBudget(4, nil)
+	x, y := fixedarr.New(10), fixedarr.New(10)
+	b.Join(x, 3)
+	b.Join(y, 0)
+	x.PushMany(1, 2, 3)
+	y.PushMany(1, 2, 3)
+
+	if want := []interface{}{1, 2, 3}; !reflect.DeepEqual(x.Value(), want) {
+		t.Fatalf("x = %v; want its guaranteed %v", x.Value(), want)
+	}
+	if want := []interface{}{3}; !reflect.DeepEqual(y.Value(), want) {
+		t.Fatalf("y = %v; want %v", y.Value(), want)
+	}
+
+	// A member at its minimum is skipped, even when it is the furthest
+	// over the fair share.
+	y.Push(4)
+	if want := []interface{}{4}; !reflect.DeepEqual(y.Value(), want) || x.Len() != 3 || b.Used() != 4 {
+		t.Fatalf("x = %v, y = %v, Used() = %d", x.Value(), y.Value(), b.Used())
+	}
+}
+
+func TestBudgetWeight(t *testing.T) {
+	b := fixedarr.NewBudget(10, func(el interface{}) int64 { return int64(len(el.(string))) })
+	x := fixedarr.New(10)
+	b.Join(x, 0)
+	x.PushMany("aaaa", "bbbb", "cc")
+	x.Push("ddd")
+	if want := []interface{}{"bbbb", "cc", "ddd"}; !reflect.DeepEqual(x.Value(), want) {
+		t.Fatalf("x = %v; want %v", x.Value(), want)
+	}
+	x.Pop()
+	if b.Used() != 5 {
+		t.Fatalf("Used() = %d after Pop; want 5", b.Used())
+	}
+	x.Reset()
+	if b.Used() != 0 {
+		t.Fatalf("Used() = %d after Reset; want 0", b.Used())
+	}
+}
+
+func TestBudgetLeave(t *testing.T) {
+	b := fixedarr.NewBudget(2, nil)
+	x, y := fixedarr.New(10), fixedarr.New(10)
+	b.Join(x, 0)
+	b.Join(y, 0)
+	x.Push(1)
+	y.Push(1)
+
+	b.Leave(y)
+	if b.Used() != 1 {
+		t.Fatalf("Used() = %d after Leave; want 1", b.Used())
+	}
+	y.PushMany(2, 3, 4)
+	if y.Len() != 4 || b.Used() != 1 {
+		t.Fatalf("y = %v, Used() = %d; a member that left is not accounted", y.Value(), b.Used())
+	}
+
+	// y can join again, and is then trimmed to the budget.
+	b.Join(y, 0)
+	if x.Len()+y.Len() != 2 || b.Used() != 2 {
+		t.Fatalf("x = %v, y = %v, Used() = %d", x.Value(), y.Value(), b.Used())
+	}
+}
+
+func TestBudgetJoinWhilePushing(t *testing.T) {
+	b := fixedarr.NewBudget(1000, nil)
+	for i := 0; i < 20; i++ {
+		x := fixedarr.New(100)
+		started, stop := make(chan struct{}), make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			x.Push(0)
+			close(started)
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					x.Push(0)
+				}
+			}
+		}()
+		<-started
+		b.Join(x, 0)
+		close(stop)
+		wg.Wait()
+
+		if got, want := b.Used(), int64(x.Len()); got != want {
+			t.Fatalf("Used() = %d; want %d", got, want)
+		}
+		// Leave subtracts what the member accounted for; any drift
+		// between the member and the budget shows up here.
+		b.Leave(x)
+		if got := b.Used(); got != 0 {
+			t.Fatalf("Used() = %d after Leave; want 0", got)
+		}
+	}
+}
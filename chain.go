@@ -0,0 +1,189 @@
+package fixedarr
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// ErrTampered is returned (wrapped) by Chain.Verify when the hash chain is
+// broken.
+var ErrTampered = errors.New("fixedarr: hash chain broken")
+
+// ChainEntry is an element of a Chain: the encoded value, the hash of the
+// previous entry, and its own hash, SHA-256(Prev || Data).
+type ChainEntry struct {
+	Data []byte
+	Prev [sha256.Size]byte
+	Hash [sha256.Size]byte
+}
+
+// Chain is a fixed size array whose entries are hash-chained, so that any
+// change to the retained entries, in memory or in a snapshot, is detected
+// by Verify. The hash of the last evicted entry is kept as the anchor of
+// the chain. A Chain only proves its own consistency: comparing Head with
+// a copy kept elsewhere also detects wholesale rewrites.
+type Chain struct {
+	arr   *Array
+	codec Codec
+}
+
+var chainEntryCodec = JSONCodec{New: func() interface{} { return new(ChainEntry) }}
+
+// NewChain returns a new Chain; values are encoded with the Codec set by
+// opts (DefaultCodec otherwise), which MUST be deterministic.
+func NewChain(maxSize int, opts ...Option) *Chain {
+	opts = append(opts, WithFold([sha256.Size]byte{}, func(_, evicted interface{}) interface{} {
+		return evicted.(ChainEntry).Hash
+	}))
+	arr := New(maxSize, opts...)
+	c := &Chain{arr: arr, codec: arr.codec}
+	arr.codec = chainEntryCodec
+	return c
+}
+
+// Push encodes v and appends it to the chain; if the chain has reached its
+// limit capacity, the oldest entry becomes the anchor.
+func (c *Chain) Push(v interface{}) error {
+	data, err := c.codec.Encode(v)
+	if err != nil {
+		return err
+	}
+	a := c.arr
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	prev := c.head()
+	a.push(ChainEntry{
+		Data: data,
+		Prev: prev,
+		Hash: chainHash(prev, data),
+	})
+	return nil
+}
+
+func chainHash(prev [sha256.Size]byte, data []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write(prev[:])
+	h.Write(data)
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// head returns the hash of the newest entry, or the anchor if the chain is
+// empty; c.arr.mu MUST be held.
+func (c *Chain) head() [sha256.Size]byte {
+	if n := len(c.arr.array); n > 0 {
+		return c.arr.array[n-1].(ChainEntry).Hash
+	}
+	return c.arr.folded.([sha256.Size]byte)
+}
+
+// Head returns the hash of the newest entry, or the anchor if the chain is
+// empty.
+func (c *Chain) Head() [sha256.Size]byte {
+	c.arr.mu.RLock()
+	defer c.arr.mu.RUnlock()
+
+	return c.head()
+}
+
+// Anchor returns the hash of the last evicted entry, which the oldest
+// retained entry chains to; it is all zeroes until the first eviction.
+func (c *Chain) Anchor() [sha256.Size]byte {
+	return c.arr.Folded().([sha256.Size]byte)
+}
+
+// Len returns the current length of the chain
+func (c *Chain) Len() int {
+	return c.arr.Len()
+}
+
+// Max returns the limit size of the chain
+func (c *Chain) Max() int {
+	return c.arr.Max()
+}
+
+// Entries returns a copy of the retained entries, oldest first; their Data
+// is copied too, so the entries can be modified freely.
+func (c *Chain) Entries() []ChainEntry {
+	c.arr.mu.RLock()
+	defer c.arr.mu.RUnlock()
+
+	entries := make([]ChainEntry, len(c.arr.array))
+	for i, el := range c.arr.array {
+		e := el.(ChainEntry)
+		e.Data = append([]byte(nil), e.Data...)
+		entries[i] = e
+	}
+	return entries
+}
+
+// Values returns the decoded values of the retained entries, oldest first.
+func (c *Chain) Values() ([]interface{}, error) {
+	entries := c.Entries()
+	values := make([]interface{}, len(entries))
+	for i, e := range entries {
+		v, err := c.codec.Decode(e.Data)
+		if err != nil {
+			return nil, fmt.Errorf("fixedarr: decoding entry %d: %w", i, err)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// Verify checks that every retained entry chains to the previous one, the
+// oldest to the anchor, and that its hash matches its contents.
+func (c *Chain) Verify() error {
+	c.arr.mu.RLock()
+	defer c.arr.mu.RUnlock()
+
+	expected := c.arr.folded.([sha256.Size]byte)
+	for i, el := range c.arr.array {
+		e := el.(ChainEntry)
+		if e.Prev != expected {
+			return fmt.Errorf("%w: entry %d does not chain to its predecessor", ErrTampered, i)
+		}
+		if chainHash(e.Prev, e.Data) != e.Hash {
+			return fmt.Errorf("%w: entry %d does not match its hash", ErrTampered, i)
+		}
+		expected = e.Hash
+	}
+	return nil
+}
+
+// MarshalBinary encodes the anchor and the entries of the chain, as
+// Array.MarshalBinary does (including its transforms); the anchor is
+// covered by the checksum, and by the seal of the transforms.
+func (c *Chain) MarshalBinary() ([]byte, error) {
+	c.arr.mu.RLock()
+	s := c.arr.capture()
+	anchor := c.arr.folded.([sha256.Size]byte)
+	c.arr.mu.RUnlock()
+
+	s.extra = anchor[:]
+	return c.arr.encode(s)
+}
+
+// UnmarshalBinary replaces the chain with one encoded by MarshalBinary; the
+// decoded chain is not verified, call Verify to detect tampering.
+func (c *Chain) UnmarshalBinary(data []byte) error {
+	s, err := c.arr.decode(data)
+	if err != nil {
+		return err
+	}
+	if len(s.extra) != sha256.Size {
+		return fmt.Errorf("%w: missing chain anchor", ErrCorrupt)
+	}
+	var anchor [sha256.Size]byte
+	copy(anchor[:], s.extra)
+
+	c.arr.mu.Lock()
+	defer c.arr.mu.Unlock()
+
+	c.arr.restore(s)
+	c.arr.folded = anchor
+	return nil
+}
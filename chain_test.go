@@ -0,0 +1,121 @@
+package fixedarr_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+func TestChain(t *testing.T) {
+	c := fixedarr.NewChain(3)
+	for i := 0; i < 5; i++ {
+		if err := c.Push(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	entries := c.Entries()
+	if len(entries) != 3 || entries[0].Prev != c.Anchor() || entries[2].Hash != c.Head() {
+		t.Fatalf("entries do not chain from Anchor to Head: %+v", entries)
+	}
+	values, err := c.Values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{2.0, 3.0, 4.0}; !reflect.DeepEqual(values, want) {
+		t.Fatalf("Values() = %v; want %v", values, want)
+	}
+
+	// Entries are deep copies.
+	entries[0].Data[0] = 'x'
+	if err := c.Verify(); err != nil {
+		t.Fatalf("modifying a copy broke the chain: %v", err)
+	}
+}
+
+func TestChainSnapshot(t *testing.T) {
+	c := fixedarr.NewChain(2)
+	for _, v := range []string{"a", "b", "c"} {
+		c.Push(v)
+	}
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := fixedarr.NewChain(1)
+	if err := d.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if d.Anchor() != c.Anchor() || d.Head() != c.Head() || d.Max() != 2 {
+		t.Fatal("restored chain differs")
+	}
+	d.Push("d")
+	if err := d.Verify(); err != nil {
+		t.Fatalf("Push after restore broke the chain: %v", err)
+	}
+
+	// The anchor is covered by the checksum.
+	anchor := c.Anchor()
+	i := bytes.Index(data, anchor[:])
+	if i < 0 {
+		t.Fatal("anchor not found in snapshot")
+	}
+	damaged := append([]byte(nil), data...)
+	damaged[i] ^= 1
+	if err := d.UnmarshalBinary(damaged); !errors.Is(err, fixedarr.ErrCorrupt) {
+		t.Fatalf("damaged anchor: error = %v; want ErrCorrupt", err)
+	}
+
+	// An array snapshot has no anchor.
+	a := fixedarr.New(2)
+	a.Push("a")
+	plain, _ := a.MarshalBinary()
+	if err := d.UnmarshalBinary(plain); !errors.Is(err, fixedarr.ErrCorrupt) {
+		t.Fatalf("array snapshot: error = %v; want ErrCorrupt", err)
+	}
+}
+
+func TestChainSnapshotSealed(t *testing.T) {
+	aead, err := fixedarr.AESGCM(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := fixedarr.NewChain(2, fixedarr.WithTransforms(aead))
+	for _, v := range []string{"a", "b", "c"} {
+		c.Push(v)
+	}
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := fixedarr.NewChain(2, fixedarr.WithTransforms(aead))
+	if err := d.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if d.Anchor() != c.Anchor() {
+		t.Fatal("restored anchor differs")
+	}
+
+	// Every byte, the anchor included, is authenticated.
+	for i := range data {
+		damaged := append([]byte(nil), data...)
+		damaged[i] ^= 1
+		if err := d.UnmarshalBinary(damaged); err == nil {
+			t.Fatalf("byte %d damaged: no error", i)
+		}
+	}
+	damaged := append([]byte(nil), data...)
+	damaged[len(damaged)-1] ^= 1
+	if err := d.UnmarshalBinary(damaged); !errors.Is(err, fixedarr.ErrAuth) {
+		t.Fatalf("error = %v; want ErrAuth", err)
+	}
+}
@@ -0,0 +1,152 @@
+package fixedarr
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Checkpointer periodically saves an Array to a file, and once more when
+// closed; every save is written to a temporary file that is atomically
+// renamed into place, so the file always holds a complete snapshot.
+type Checkpointer struct {
+	arr  *Array
+	path string
+
+	mu      *sync.Mutex // serializes saves
+	errMu   *sync.Mutex
+	lastErr error
+
+	stop chan struct{}
+	done chan struct{}
+	once *sync.Once
+}
+
+// NewCheckpointer starts saving arr to path every interval, measured by
+// clock (SystemClock if nil); interval MUST be a positive duration.
+func NewCheckpointer(arr *Array, path string, interval time.Duration, clock Clock) *Checkpointer {
+	if interval <= 0 {
+		panic("fixedarr.NewCheckpointer: interval must be positive")
+	}
+	if clock == nil {
+		clock = SystemClock
+	}
+	c := &Checkpointer{
+		arr:   arr,
+		path:  path,
+		mu:    &sync.Mutex{},
+		errMu: &sync.Mutex{},
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+		once:  &sync.Once{},
+	}
+	ticker := clock.NewTicker(interval)
+	go c.loop(ticker)
+	return c
+}
+
+func (c *Checkpointer) loop(ticker Ticker) {
+	defer close(c.done)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			err := c.Checkpoint()
+			c.errMu.Lock()
+			c.lastErr = err
+			c.errMu.Unlock()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// Checkpoint saves the array now.
+func (c *Checkpointer) Checkpoint() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := c.arr.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, data)
+}
+
+// Err returns the error of the last periodic save, if any.
+func (c *Checkpointer) Err() error {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+
+	return c.lastErr
+}
+
+// Close stops the periodic saves and saves the array a last time.
+func (c *Checkpointer) Close() error {
+	err := errors.New("fixedarr: Checkpointer already closed")
+	c.once.Do(func() {
+		close(c.stop)
+		<-c.done
+		err = c.Checkpoint()
+	})
+	return err
+}
+
+// Restore returns the Array saved at path by a Checkpointer; opts MUST
+// include the same WithCodec and WithTransforms the saved array was created
+// with, if any. A truncated or corrupt file results in an error wrapping
+// ErrCorrupt, and a tampered one (or a wrong key) in one wrapping ErrAuth.
+func Restore(path string, opts ...Option) (*Array, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	a := New(0, opts...)
+	if err := a.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("fixedarr.Restore %s: %w", path, err)
+	}
+	return a, nil
+}
+
+// writeFileAtomic writes data to a temporary file in the directory of path,
+// flushes it to stable storage and renames it to path.
+func writeFileAtomic(path string, data []byte) error {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, base+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	// Persist the rename itself; not every platform supports syncing a
+	// directory, so failures are ignored.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package fixedarr_test
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+// waitRestored waits for path to hold a snapshot of an array holding want.
+func waitRestored(t *testing.T, path string, want []interface{}) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		a, err := fixedarr.Restore(path)
+		if err == nil && reflect.DeepEqual(a.Value(), want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Restore() = %v, %v; want %v", a, err, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCheckpointerPeriodic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "arr")
+	clock := fixedarr.NewManualClock(time.Unix(0, 0))
+	a := fixedarr.New(4)
+	a.PushMany(1.0, 2.0)
+	cp := fixedarr.NewCheckpointer(a, path, time.Minute, clock)
+
+	clock.Advance(time.Minute)
+	waitRestored(t, path, []interface{}{1.0, 2.0})
+	a.Push(3.0)
+	clock.Advance(time.Minute)
+	waitRestored(t, path, []interface{}{1.0, 2.0, 3.0})
+	if err := cp.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	// Close saves the array a last time.
+	a.Push(4.0)
+	if err := cp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	waitRestored(t, path, []interface{}{1.0, 2.0, 3.0, 4.0})
+	if err := cp.Close(); err == nil {
+		t.Fatal("second Close() = nil; want an error")
+	}
+	if n := clock.Waiters(); n != 0 {
+		t.Fatalf("Waiters() = %d after Close; want 0", n)
+	}
+}
+
+func TestCheckpointerErr(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "arr")
+	clock := fixedarr.NewManualClock(time.Unix(0, 0))
+	cp := fixedarr.NewCheckpointer(fixedarr.New(4), path, time.Minute, clock)
+
+	clock.Advance(time.Minute)
+	deadline := time.Now().Add(5 * time.Second)
+	for cp.Err() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Err() = nil after a failed periodic save")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := cp.Err(); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Err() = %v; want fs.ErrNotExist", err)
+	}
+	if err := cp.Close(); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Close() = %v; want fs.ErrNotExist", err)
+	}
+}
+
+func TestRestoreMissing(t *testing.T) {
+	_, err := fixedarr.Restore(filepath.Join(t.TempDir(), "arr"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Restore() error = %v; want fs.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,210 @@
+package fixedarr
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time used by every time-aware type of this package;
+// use SystemClock in production and a ManualClock in tests.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is the Clock counterpart of time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is the Clock counterpart of time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// WithClock sets the Clock used by the time-aware features of the array;
+// the default is SystemClock.
+func WithClock(c Clock) Option {
+	return func(a *Array) {
+		a.clock = c
+	}
+}
+
+// SystemClock is the Clock backed by the time package.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTimer struct{ t *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time        { return t.t.C }
+func (t systemTimer) Stop() bool                 { return t.t.Stop() }
+func (t systemTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+type systemTicker struct{ t *time.Ticker }
+
+func (t systemTicker) C() <-chan time.Time   { return t.t.C }
+func (t systemTicker) Stop()                 { t.t.Stop() }
+func (t systemTicker) Reset(d time.Duration) { t.t.Reset(d) }
+
+// ManualClock is a Clock whose time only moves when Advance is called;
+// timers and tickers fire synchronously from within Advance.
+type ManualClock struct {
+	mu      *sync.Mutex
+	now     time.Time
+	waiters []*manualWaiter
+}
+
+// NewManualClock returns a new ManualClock set to start.
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{
+		mu:  &sync.Mutex{},
+		now: start,
+	}
+}
+
+// Now returns the current time of the clock.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Advance moves the clock forward by d, firing in order every timer and
+// ticker that expires in the meantime.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	end := c.now.Add(d)
+	for {
+		var next *manualWaiter
+		for _, w := range c.waiters {
+			if !w.when.After(end) && (next == nil || w.when.Before(next.when)) {
+				next = w
+			}
+		}
+		if next == nil {
+			break
+		}
+		if next.when.After(c.now) {
+			c.now = next.when
+		}
+		select {
+		case next.ch <- c.now:
+		default:
+		}
+		if next.period > 0 {
+			next.when = next.when.Add(next.period)
+		} else {
+			c.remove(next)
+		}
+	}
+	c.now = end
+}
+
+// Waiters returns the number of active timers and tickers; tests can poll
+// it to know when a goroutine has started waiting on the clock.
+func (c *ManualClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.waiters)
+}
+
+// NewTimer returns a Timer firing once the clock has advanced by d.
+func (c *ManualClock) NewTimer(d time.Duration) Timer {
+	w := &manualWaiter{clock: c, ch: make(chan time.Time, 1)}
+	w.Reset(d)
+	return w
+}
+
+// NewTicker returns a Ticker firing every time the clock advances by d;
+// d MUST be a positive duration.
+func (c *ManualClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("fixedarr.ManualClock.NewTicker: non-positive interval")
+	}
+	w := &manualWaiter{clock: c, ch: make(chan time.Time, 1), period: d}
+	w.Reset(d)
+	return manualTicker{w}
+}
+
+func (c *ManualClock) add(w *manualWaiter) bool {
+	for _, other := range c.waiters {
+		if other == w {
+			return true
+		}
+	}
+	c.waiters = append(c.waiters, w)
+	return false
+}
+
+func (c *ManualClock) remove(w *manualWaiter) bool {
+	for i, other := range c.waiters {
+		if other == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type manualWaiter struct {
+	clock  *ManualClock
+	ch     chan time.Time
+	when   time.Time
+	period time.Duration
+}
+
+func (w *manualWaiter) C() <-chan time.Time {
+	return w.ch
+}
+
+func (w *manualWaiter) Stop() bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+
+	return w.clock.remove(w)
+}
+
+func (w *manualWaiter) Reset(d time.Duration) bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+
+	w.when = w.clock.now.Add(d)
+	return w.clock.add(w)
+}
+
+type manualTicker struct{ w *manualWaiter }
+
+func (t manualTicker) C() <-chan time.Time { return t.w.ch }
+func (t manualTicker) Stop()               { t.w.Stop() }
+
+func (t manualTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("fixedarr.ManualClock: non-positive interval for Ticker.Reset")
+	}
+	t.w.clock.mu.Lock()
+	t.w.period = d
+	t.w.clock.mu.Unlock()
+	t.w.Reset(d)
+}
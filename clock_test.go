@@ -0,0 +1,100 @@
+package fixedarr_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+func TestManualClockAdvance(t *testing.T) {
+	start := time.Unix(0, 0)
+	c := fixedarr.NewManualClock(start)
+	timer := c.NewTimer(5 * time.Second)
+	ticker := c.NewTicker(2 * time.Second)
+
+	c.Advance(3 * time.Second)
+	if got, want := <-ticker.C(), start.Add(2*time.Second); !got.Equal(want) {
+		t.Fatalf("ticker fired at %v; want %v", got, want)
+	}
+	select {
+	case got := <-timer.C():
+		t.Fatalf("timer fired early, at %v", got)
+	default:
+	}
+
+	// The ticker fires at 4s before the timer does at 5s; its channel
+	// holds a single tick, so the one at 6s is dropped.
+	c.Advance(4 * time.Second)
+	if got, want := <-ticker.C(), start.Add(4*time.Second); !got.Equal(want) {
+		t.Fatalf("ticker fired at %v; want %v", got, want)
+	}
+	if got, want := <-timer.C(), start.Add(5*time.Second); !got.Equal(want) {
+		t.Fatalf("timer fired at %v; want %v", got, want)
+	}
+	if got, want := c.Now(), start.Add(7*time.Second); !got.Equal(want) {
+		t.Fatalf("Now() = %v; want %v", got, want)
+	}
+
+	if n := c.Waiters(); n != 1 {
+		t.Fatalf("Waiters() = %d; want 1", n)
+	}
+	if timer.Stop() {
+		t.Fatal("Stop() = true for a fired timer")
+	}
+	ticker.Stop()
+	if n := c.Waiters(); n != 0 {
+		t.Fatalf("Waiters() = %d after Stop; want 0", n)
+	}
+}
+
+func TestManualClockOrder(t *testing.T) {
+	c := fixedarr.NewManualClock(time.Unix(0, 0))
+	deadlines := []int64{3, 1, 2}
+	var timers []fixedarr.Timer
+	for _, d := range deadlines {
+		timers = append(timers, c.NewTimer(time.Duration(d)*time.Second))
+	}
+
+	// A single Advance past every deadline fires each timer at its own
+	// deadline, not at the end of the advance.
+	c.Advance(time.Minute)
+	for i, timer := range timers {
+		if got, want := <-timer.C(), time.Unix(deadlines[i], 0); !got.Equal(want) {
+			t.Fatalf("timer %d fired at %v; want %v", i, got, want)
+		}
+	}
+	if n := c.Waiters(); n != 0 {
+		t.Fatalf("Waiters() = %d; want 0", n)
+	}
+}
+
+func TestManualClockReset(t *testing.T) {
+	c := fixedarr.NewManualClock(time.Unix(0, 0))
+	timer := c.NewTimer(time.Second)
+	if !timer.Reset(3 * time.Second) {
+		t.Fatal("Reset() = false for an active timer")
+	}
+	c.Advance(2 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired before its reset deadline")
+	default:
+	}
+	c.Advance(time.Second)
+	<-timer.C()
+}
+
+func TestWithClock(t *testing.T) {
+	c := fixedarr.NewManualClock(time.Unix(100, 0))
+	ctx := fixedarr.WithBreadcrumbs(context.Background(), 2, fixedarr.WithClock(c))
+	fixedarr.Breadcrumb(ctx, "a", nil)
+	c.Advance(time.Second)
+	fixedarr.Breadcrumb(ctx, "b", nil)
+
+	crumbs := fixedarr.Breadcrumbs(ctx)
+	if len(crumbs) != 2 || !crumbs[0].Time.Equal(time.Unix(100, 0)) || !crumbs[1].Time.Equal(time.Unix(101, 0)) {
+		t.Fatalf("Breadcrumbs() = %v", crumbs)
+	}
+}
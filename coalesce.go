@@ -0,0 +1,52 @@
+package fixedarr
+
+import "time"
+
+// Coalesced is an element of an array created with WithCoalesce: Value was
+// pushed Count consecutive times, first at FirstSeen and last at LastSeen.
+type Coalesced struct {
+	Value     interface{}
+	Count     int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// WithCoalesce makes Push coalesce consecutive duplicates: an element equal
+// (by equal) to the newest one only increments the Count of the newest
+// one. The array then holds Coalesced elements, timestamped with its
+// Clock. Derive views and the weight func of a Budget still see the
+// elements as pushed, and only once per run of duplicates. Snapshots keep
+// the counts and timestamps, and encode the values with the Codec of the
+// array.
+func WithCoalesce(equal func(a, b interface{}) bool) Option {
+	return func(a *Array) {
+		a.coalesce = equal
+	}
+}
+
+// coalesced returns el wrapped in a Coalesced; if el is equal to the newest
+// element, it counts it there instead, and returns false. a.mu MUST be held.
+func (a *Array) coalesced(el interface{}) (interface{}, bool) {
+	now := a.clock.Now()
+	if n := len(a.array); n > 0 {
+		last, ok := a.array[n-1].(Coalesced)
+		if ok && a.coalesce(last.Value, el) {
+			last.Count++
+			last.LastSeen = now
+			a.array[n-1] = last
+			return nil, false
+		}
+	}
+	return Coalesced{Value: el, Count: 1, FirstSeen: now, LastSeen: now}, true
+}
+
+// raw returns el as it was pushed, unwrapping it if the array coalesces.
+func (a *Array) raw(el interface{}) interface{} {
+	if a.coalesce == nil {
+		return el
+	}
+	if c, ok := el.(Coalesced); ok {
+		return c.Value
+	}
+	return el
+}
@@ -0,0 +1,114 @@
+package fixedarr_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+func same(a, b interface{}) bool { return a == b }
+
+func TestCoalesce(t *testing.T) {
+	clock := fixedarr.NewManualClock(time.Unix(0, 0))
+	a := fixedarr.New(2, fixedarr.WithCoalesce(same), fixedarr.WithClock(clock))
+	for _, v := range []string{"a", "a", "b", "a", "a", "a"} {
+		a.Push(v)
+		clock.Advance(time.Second)
+	}
+
+	want := []interface{}{
+		fixedarr.Coalesced{Value: "b", Count: 1, FirstSeen: time.Unix(2, 0), LastSeen: time.Unix(2, 0)},
+		fixedarr.Coalesced{Value: "a", Count: 3, FirstSeen: time.Unix(3, 0), LastSeen: time.Unix(5, 0)},
+	}
+	if got := a.Value(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Value() = %v; want %v", got, want)
+	}
+}
+
+func TestCoalesceDerive(t *testing.T) {
+	a := fixedarr.New(10, fixedarr.WithCoalesce(same))
+	a.PushMany(1, "x")
+	isInt := func(el interface{}) bool { _, ok := el.(int); return ok }
+	v := fixedarr.Derive(a, isInt, nil, 10)
+	a.PushMany(2, 2, 2, "y", 3)
+
+	// The view sees the elements as pushed, once per run of duplicates.
+	if got, want := v.Value(), []interface{}{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("view = %v; want %v", got, want)
+	}
+}
+
+func TestCoalesceBudget(t *testing.T) {
+	var weighed []interface{}
+	budget := fixedarr.NewBudget(6, func(el interface{}) int64 {
+		weighed = append(weighed, el)
+		return int64(len(el.(string)))
+	})
+	a := fixedarr.New(10, fixedarr.WithCoalesce(same))
+	budget.Join(a, 0)
+	a.PushMany("aa", "aa", "bb", "cc")
+	if budget.Used() != 6 {
+		t.Fatalf("Used() = %d; want 6", budget.Used())
+	}
+	a.Push("dd") // evicts the coalesced "aa"
+	if budget.Used() != 6 || a.Len() != 3 {
+		t.Fatalf("Used() = %d, Len() = %d; want 6, 3", budget.Used(), a.Len())
+	}
+	for _, el := range weighed {
+		if _, ok := el.(string); !ok {
+			t.Fatalf("the weight func was passed a %T", el)
+		}
+	}
+}
+
+func TestCoalesceSnapshot(t *testing.T) {
+	clock := fixedarr.NewManualClock(time.Unix(0, 0))
+	a := fixedarr.New(3, fixedarr.WithCoalesce(same), fixedarr.WithClock(clock))
+	for _, v := range []string{"a", "a", "b"} {
+		a.Push(v)
+		clock.Advance(time.Second)
+	}
+	data, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The values decode with the JSONCodec of the array, and keep their
+	// counts and timestamps.
+	b := fixedarr.New(0, fixedarr.WithCoalesce(same), fixedarr.WithClock(clock))
+	var weighed []interface{}
+	budget := fixedarr.NewBudget(100, func(el interface{}) int64 {
+		weighed = append(weighed, el)
+		return 1
+	})
+	budget.Join(b, 0)
+	if err := b.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"a", "b"}; !reflect.DeepEqual(weighed, want) {
+		t.Fatalf("Budget weighed %v; want %v", weighed, want)
+	}
+	view := fixedarr.Derive(b, nil, nil, 10)
+	b.Push("b")
+
+	want := []fixedarr.Coalesced{
+		{Value: "a", Count: 2, FirstSeen: time.Unix(0, 0), LastSeen: time.Unix(1, 0)},
+		{Value: "b", Count: 2, FirstSeen: time.Unix(2, 0), LastSeen: time.Unix(3, 0)},
+	}
+	got := b.Value()
+	if len(got) != len(want) {
+		t.Fatalf("Value() = %v; want %v", got, want)
+	}
+	for i, el := range got {
+		c, ok := el.(fixedarr.Coalesced)
+		if !ok || c.Value != want[i].Value || c.Count != want[i].Count ||
+			!c.FirstSeen.Equal(want[i].FirstSeen) || !c.LastSeen.Equal(want[i].LastSeen) {
+			t.Fatalf("element %d = %#v; want %v", i, el, want[i])
+		}
+	}
+	if got, want := view.Value(), []interface{}{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("view = %v; want %v", got, want)
+	}
+}
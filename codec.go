@@ -0,0 +1,218 @@
+package fixedarr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// Codec encodes and decodes the elements of an Array, one at a time or as
+// a stream; it is used by every serialization entry point of the package.
+type Codec interface {
+	Encode(v interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Encoder writes a stream of elements.
+type Encoder interface {
+	Encode(v interface{}) error
+}
+
+// Decoder reads a stream of elements; Decode returns io.EOF at the end of
+// the stream.
+type Decoder interface {
+	Decode() (interface{}, error)
+}
+
+// DefaultCodec is the Codec used by arrays created without WithCodec.
+var DefaultCodec Codec = JSONCodec{}
+
+// newValue returns the pointer an element is decoded into: the one returned
+// by newFn, or a pointer to a nil interface{} when newFn is nil.
+func newValue(newFn func() interface{}) interface{} {
+	if newFn == nil {
+		return new(interface{})
+	}
+	return newFn()
+}
+
+// deref returns the value p points to.
+func deref(p interface{}) interface{} {
+	return reflect.ValueOf(p).Elem().Interface()
+}
+
+// JSONCodec encodes elements as JSON. New, if set, returns a pointer to a
+// fresh value to decode into (e.g. func() interface{} { return new(Event) }),
+// and the pointed value is returned; otherwise elements decode to the
+// generic types of encoding/json.
+type JSONCodec struct {
+	New func() interface{}
+}
+
+// Encode returns the JSON encoding of v.
+func (c JSONCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Decode decodes a JSON-encoded element.
+func (c JSONCodec) Decode(data []byte) (interface{}, error) {
+	p := newValue(c.New)
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	return deref(p), nil
+}
+
+// NewEncoder returns an Encoder writing newline-delimited JSON to w.
+func (c JSONCodec) NewEncoder(w io.Writer) Encoder {
+	return jsonEncoder{json.NewEncoder(w)}
+}
+
+// NewDecoder returns a Decoder reading a stream of JSON values from r.
+func (c JSONCodec) NewDecoder(r io.Reader) Decoder {
+	return jsonDecoder{c.New, json.NewDecoder(r)}
+}
+
+type jsonEncoder struct{ enc *json.Encoder }
+
+func (e jsonEncoder) Encode(v interface{}) error {
+	return e.enc.Encode(v)
+}
+
+type jsonDecoder struct {
+	newFn func() interface{}
+	dec   *json.Decoder
+}
+
+func (d jsonDecoder) Decode() (interface{}, error) {
+	p := newValue(d.newFn)
+	if err := d.dec.Decode(p); err != nil {
+		return nil, err
+	}
+	return deref(p), nil
+}
+
+// GobCodec encodes elements with encoding/gob. New, if set, returns a
+// pointer to a fresh value to decode into, as for JSONCodec; otherwise
+// elements are encoded as interface values, and their concrete types MUST
+// be registered with gob.Register.
+type GobCodec struct {
+	New func() interface{}
+}
+
+// Encode returns the gob encoding of v.
+func (c GobCodec) Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decodes a gob-encoded element.
+func (c GobCodec) Decode(data []byte) (interface{}, error) {
+	return c.NewDecoder(bytes.NewReader(data)).Decode()
+}
+
+// NewEncoder returns an Encoder writing a gob stream to w.
+func (c GobCodec) NewEncoder(w io.Writer) Encoder {
+	return gobEncoder{c.New != nil, gob.NewEncoder(w)}
+}
+
+// NewDecoder returns a Decoder reading a gob stream from r.
+func (c GobCodec) NewDecoder(r io.Reader) Decoder {
+	return gobDecoder{c.New, gob.NewDecoder(r)}
+}
+
+type gobEncoder struct {
+	concrete bool
+	enc      *gob.Encoder
+}
+
+func (e gobEncoder) Encode(v interface{}) error {
+	if e.concrete {
+		return e.enc.Encode(v)
+	}
+	return e.enc.Encode(&v)
+}
+
+type gobDecoder struct {
+	newFn func() interface{}
+	dec   *gob.Decoder
+}
+
+func (d gobDecoder) Decode() (interface{}, error) {
+	p := newValue(d.newFn)
+	if err := d.dec.Decode(p); err != nil {
+		return nil, err
+	}
+	return deref(p), nil
+}
+
+// RawCodec stores []byte (or string) elements as they are; elements decode
+// to []byte. Streams are sequences of length-prefixed frames.
+type RawCodec struct{}
+
+// Encode returns v, which MUST be a []byte or a string.
+func (RawCodec) Encode(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	return nil, fmt.Errorf("fixedarr: RawCodec cannot encode %T", v)
+}
+
+// Decode returns a copy of data.
+func (RawCodec) Decode(data []byte) (interface{}, error) {
+	return append([]byte{}, data...), nil
+}
+
+// NewEncoder returns an Encoder writing length-prefixed frames to w.
+func (c RawCodec) NewEncoder(w io.Writer) Encoder {
+	return rawEncoder{w}
+}
+
+// NewDecoder returns a Decoder reading length-prefixed frames from r.
+func (c RawCodec) NewDecoder(r io.Reader) Decoder {
+	return rawDecoder{bufio.NewReader(r)}
+}
+
+type rawEncoder struct{ w io.Writer }
+
+func (e rawEncoder) Encode(v interface{}) error {
+	data, err := RawCodec{}.Encode(v)
+	if err != nil {
+		return err
+	}
+	if _, err := e.w.Write(binary.AppendUvarint(nil, uint64(len(data)))); err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+type rawDecoder struct{ r *bufio.Reader }
+
+func (d rawDecoder) Decode() (interface{}, error) {
+	n, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(d.r, int64(n)))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) != n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data, nil
+}
@@ -0,0 +1,124 @@
+package fixedarr_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+type point struct {
+	X, Y int
+}
+
+func init() {
+	gob.Register(point{})
+}
+
+// roundTrip encodes els one at a time and as a stream with c, and checks
+// that both decode to want.
+func roundTrip(t *testing.T, c fixedarr.Codec, els, want []interface{}) {
+	t.Helper()
+	for i, el := range els {
+		data, err := c.Encode(el)
+		if err != nil {
+			t.Fatalf("Encode(%v): %v", el, err)
+		}
+		got, err := c.Decode(data)
+		if err != nil {
+			t.Fatalf("Decode(Encode(%v)): %v", el, err)
+		}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Fatalf("Decode(Encode(%v)) = %#v; want %#v", el, got, want[i])
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := c.NewEncoder(&buf)
+	for _, el := range els {
+		if err := enc.Encode(el); err != nil {
+			t.Fatalf("Encoder.Encode(%v): %v", el, err)
+		}
+	}
+	dec := c.NewDecoder(&buf)
+	var got []interface{}
+	for {
+		el, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Decoder.Decode: %v", err)
+		}
+		got = append(got, el)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("stream decoded to %#v; want %#v", got, want)
+	}
+}
+
+func TestGobCodec(t *testing.T) {
+	// Without New, the concrete types travel with the elements.
+	els := []interface{}{point{1, 2}, "a", 3}
+	roundTrip(t, fixedarr.GobCodec{}, els, els)
+
+	// With New, every element decodes to the type it returns.
+	c := fixedarr.GobCodec{New: func() interface{} { return new(point) }}
+	els = []interface{}{point{1, 2}, point{3, 4}}
+	roundTrip(t, c, els, els)
+
+	a := fixedarr.New(2, fixedarr.WithCodec(c))
+	a.PushMany(point{1, 2}, point{3, 4}, point{5, 6})
+	data, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := fixedarr.New(0, fixedarr.WithCodec(c))
+	if err := b.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{point{3, 4}, point{5, 6}}; !reflect.DeepEqual(b.Value(), want) {
+		t.Fatalf("Value() = %v; want %v", b.Value(), want)
+	}
+}
+
+func TestRawCodec(t *testing.T) {
+	c := fixedarr.RawCodec{}
+	roundTrip(t, c,
+		[]interface{}{[]byte("ab"), "cd", []byte{}},
+		[]interface{}{[]byte("ab"), []byte("cd"), []byte{}})
+
+	// Decode copies its input.
+	data := []byte("ab")
+	el, err := c.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[0] = 'x'
+	if !bytes.Equal(el.([]byte), []byte("ab")) {
+		t.Fatalf("Decode() = %q after its input changed; want \"ab\"", el)
+	}
+
+	if _, err := c.Encode(1); err == nil {
+		t.Fatal("Encode(1) = nil error")
+	}
+	var buf bytes.Buffer
+	if err := c.NewEncoder(&buf).Encode(1); err == nil {
+		t.Fatal("Encoder.Encode(1) = nil error")
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("Encoder.Encode(1) wrote %d bytes", buf.Len())
+	}
+
+	// A frame cut short is an error, not the end of the stream.
+	buf.Reset()
+	c.NewEncoder(&buf).Encode("abc")
+	_, err = c.NewDecoder(bytes.NewReader(buf.Bytes()[:2])).Decode()
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Decode() of a truncated frame = %v; want io.ErrUnexpectedEOF", err)
+	}
+}
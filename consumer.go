@@ -0,0 +1,122 @@
+package fixedarr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// Offsets stores the committed cursors of named consumers in a file, like
+// the consumer groups of a log; the array they read is usually persisted by
+// a Checkpointer, whose snapshots keep the sequence numbers of the elements
+// the cursors refer to once restored into a new array (see Restore).
+//
+// The array MUST be restored from a snapshot saved after the cursors were
+// committed, or new elements may reuse sequence numbers consumers have
+// already gone past: checkpoint the array before committing.
+type Offsets struct {
+	path string
+
+	mu      *sync.Mutex
+	cursors map[string]uint64
+}
+
+// OpenOffsets returns the Offsets stored at path; a missing file holds no
+// cursors, and is created by the first Commit.
+func OpenOffsets(path string) (*Offsets, error) {
+	o := &Offsets{
+		path:    path,
+		mu:      &sync.Mutex{},
+		cursors: map[string]uint64{},
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &o.cursors); err != nil {
+		return nil, fmt.Errorf("fixedarr.OpenOffsets %s: %w: %v", path, ErrCorrupt, err)
+	}
+	return o, nil
+}
+
+// Consumer returns the consumer of arr called name, positioned at its
+// committed cursor, or at the start of arr if it never committed.
+func (o *Offsets) Consumer(arr *Array, name string) *Consumer {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return &Consumer{
+		offsets: o,
+		arr:     arr,
+		name:    name,
+		cursor:  o.cursors[name],
+	}
+}
+
+// commit stores cursor as the one of name, and saves the file.
+func (o *Offsets) commit(name string, cursor uint64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.cursors[name] = cursor
+	data, err := json.Marshal(o.cursors)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(o.path, data)
+}
+
+// Consumer reads the elements of an array in order, from a cursor: the
+// sequence number of the last element it read. It is not safe for
+// concurrent use.
+type Consumer struct {
+	offsets *Offsets
+	arr     *Array
+	name    string
+	cursor  uint64
+}
+
+// Poll returns up to max elements (all of them if max is not positive)
+// pushed after the cursor, oldest first, and moves the cursor past them;
+// missed is the number of elements pushed after the cursor that were
+// evicted or removed before they could be read.
+func (c *Consumer) Poll(max int) (els []interface{}, missed uint64) {
+	a := c.arr
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	i, _ := a.index(Handle(c.cursor + 1))
+	j := len(a.seqs)
+	if max > 0 && j-i > max {
+		j = i + max
+	}
+	last := a.next
+	if j > i {
+		last = a.seqs[j-1]
+	}
+	if last <= c.cursor {
+		return nil, 0 // the array was restored from an older snapshot
+	}
+	els = make([]interface{}, j-i)
+	copy(els, a.array[i:j])
+	missed = last - c.cursor - uint64(j-i)
+	c.cursor = last
+	return els, missed
+}
+
+// Cursor returns the sequence number of the last element read.
+func (c *Consumer) Cursor() uint64 {
+	return c.cursor
+}
+
+// Commit stores the cursor, so that the consumer resumes from it after a
+// restart.
+func (c *Consumer) Commit() error {
+	return c.offsets.commit(c.name, c.cursor)
+}
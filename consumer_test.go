@@ -0,0 +1,108 @@
+package fixedarr_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+func TestConsumerPoll(t *testing.T) {
+	o, err := fixedarr.OpenOffsets(filepath.Join(t.TempDir(), "offsets"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := fixedarr.New(3)
+	c := o.Consumer(a, "c")
+	if els, missed := c.Poll(0); len(els) != 0 || missed != 0 {
+		t.Fatalf("Poll(0) = %v, %d on an empty array", els, missed)
+	}
+
+	a.PushMany(1, 2, 3)
+	els, missed := c.Poll(2)
+	if want := []interface{}{1, 2}; !reflect.DeepEqual(els, want) || missed != 0 || c.Cursor() != 2 {
+		t.Fatalf("Poll(2) = %v, %d (cursor %d); want %v, 0 (cursor 2)", els, missed, c.Cursor(), want)
+	}
+
+	a.PushMany(4, 5, 6) // evicts 3
+	els, missed = c.Poll(0)
+	if want := []interface{}{4, 5, 6}; !reflect.DeepEqual(els, want) || missed != 1 {
+		t.Fatalf("Poll(0) = %v, %d; want %v, 1", els, missed, want)
+	}
+
+	// Everything pushed since was evicted or removed.
+	a.PushMany(7, 8, 9, 10)
+	a.Reset()
+	if els, missed := c.Poll(0); len(els) != 0 || missed != 4 || c.Cursor() != 10 {
+		t.Fatalf("Poll(0) = %v, %d (cursor %d); want none, 4 (cursor 10)", els, missed, c.Cursor())
+	}
+}
+
+func TestConsumerResumesAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	arrPath, offPath := filepath.Join(dir, "array"), filepath.Join(dir, "offsets")
+
+	a := fixedarr.New(4)
+	a.PushMany(1, 2, 3, 4, 5)
+	o, err := fixedarr.OpenOffsets(offPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast, slow := o.Consumer(a, "fast"), o.Consumer(a, "slow")
+	fast.Poll(0)
+	slow.Poll(1)
+	a.PushMany(6, 7, 8)
+
+	// Checkpoint the array before committing, as Offsets requires.
+	cp := fixedarr.NewCheckpointer(a, arrPath, time.Hour, fixedarr.NewManualClock(time.Unix(0, 0)))
+	if err := cp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fast.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := slow.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restart.
+	b, err := fixedarr.Restore(arrPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err = fixedarr.OpenOffsets(offPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast, slow = o.Consumer(b, "fast"), o.Consumer(b, "slow")
+	if fast.Cursor() != 5 || slow.Cursor() != 2 {
+		t.Fatalf("cursors = %d, %d; want 5, 2", fast.Cursor(), slow.Cursor())
+	}
+	els, missed := fast.Poll(0)
+	if want := []interface{}{6.0, 7.0, 8.0}; !reflect.DeepEqual(els, want) || missed != 0 {
+		t.Fatalf("fast.Poll(0) = %v, %d; want %v, 0", els, missed, want)
+	}
+	els, missed = slow.Poll(0)
+	if want := []interface{}{5.0, 6.0, 7.0, 8.0}; !reflect.DeepEqual(els, want) || missed != 2 {
+		t.Fatalf("slow.Poll(0) = %v, %d; want %v, 2", els, missed, want)
+	}
+
+	// A consumer that never committed starts from the oldest element.
+	if els, missed := o.Consumer(b, "new").Poll(0); len(els) != 4 || missed != 4 {
+		t.Fatalf("new.Poll(0) = %v, %d; want 4 elements, 4 missed", els, missed)
+	}
+}
+
+func TestOpenOffsetsCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "offsets")
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fixedarr.OpenOffsets(path); !errors.Is(err, fixedarr.ErrCorrupt) {
+		t.Fatalf("OpenOffsets error = %v; want ErrCorrupt", err)
+	}
+}
@@ -0,0 +1,78 @@
+package fixedarr
+
+// View is a read-only array derived from a source Array, kept up to date
+// as the source receives elements; see Derive.
+type View struct {
+	src    *Array
+	arr    *Array
+	filter func(el interface{}) bool
+	mapFn  func(el interface{}) interface{}
+}
+
+// Derive returns a View holding, out of the elements pushed to src, the
+// last maxSize ones accepted by filter, transformed by mapFn; a nil filter
+// accepts every element, and a nil mapFn keeps elements as they are.
+// The View starts from the current elements of src, receives every Push to
+// src, and is emptied when src is reset. filter and mapFn are called with
+// the lock of src held, and MUST NOT use src.
+func Derive(src *Array, filter func(el interface{}) bool, mapFn func(el interface{}) interface{}, maxSize int) *View {
+	v := &View{
+		src:    src,
+		arr:    New(maxSize),
+		filter: filter,
+		mapFn:  mapFn,
+	}
+
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	for _, el := range src.array {
+		v.offer(src.raw(el))
+	}
+	src.views = append(src.views, v)
+	return v
+}
+
+// offer pushes el to the view if accepted by its filter; the lock of the
+// source MUST be held.
+func (v *View) offer(el interface{}) {
+	if v.filter != nil && !v.filter(el) {
+		return
+	}
+	if v.mapFn != nil {
+		el = v.mapFn(el)
+	}
+	v.arr.Push(el)
+}
+
+// Len returns the current length of the view
+func (v *View) Len() int {
+	return v.arr.Len()
+}
+
+// Max returns the limit size of the view
+func (v *View) Max() int {
+	return v.arr.Max()
+}
+
+// Value returns a copy of the current view
+func (v *View) Value() []interface{} {
+	v.arr.mu.RLock()
+	defer v.arr.mu.RUnlock()
+
+	return append([]interface{}{}, v.arr.array...)
+}
+
+// Close detaches the view from its source; it keeps its current elements,
+// but no longer receives new ones.
+func (v *View) Close() {
+	v.src.mu.Lock()
+	defer v.src.mu.Unlock()
+
+	for i, other := range v.src.views {
+		if other == v {
+			v.src.views = append(v.src.views[:i], v.src.views[i+1:]...)
+			return
+		}
+	}
+}
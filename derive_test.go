@@ -0,0 +1,76 @@
+package fixedarr_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+func even(el interface{}) bool { return el.(int)%2 == 0 }
+
+func double(el interface{}) interface{} { return el.(int) * 2 }
+
+func TestDerive(t *testing.T) {
+	src := fixedarr.New(10)
+	src.PushMany(1, 2, 3, 4)
+
+	// The view starts from the elements of src, and keeps the last
+	// maxSize ones accepted by filter, transformed by mapFn.
+	v := fixedarr.Derive(src, even, double, 2)
+	if want := []interface{}{4, 8}; !reflect.DeepEqual(v.Value(), want) {
+		t.Fatalf("Value() = %v; want %v", v.Value(), want)
+	}
+	src.PushMany(5, 6, 7, 8)
+	if want := []interface{}{12, 16}; !reflect.DeepEqual(v.Value(), want) {
+		t.Fatalf("Value() = %v; want %v", v.Value(), want)
+	}
+	if v.Len() != 2 || v.Max() != 2 {
+		t.Fatalf("Len(), Max() = %d, %d; want 2, 2", v.Len(), v.Max())
+	}
+
+	// Without filter and mapFn, the view holds the elements as they are.
+	all := fixedarr.Derive(src, nil, nil, 3)
+	if want := []interface{}{6, 7, 8}; !reflect.DeepEqual(all.Value(), want) {
+		t.Fatalf("Value() = %v; want %v", all.Value(), want)
+	}
+
+	src.Reset()
+	if v.Len() != 0 || all.Len() != 0 {
+		t.Fatalf("Len() = %d, %d after the source was reset; want 0, 0", v.Len(), all.Len())
+	}
+}
+
+func TestDeriveUnmarshal(t *testing.T) {
+	saved := fixedarr.New(4)
+	saved.PushMany(1, 2, 3, 4)
+	data, err := saved.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The view drops its elements and is re-populated from the restored
+	// ones, which decode as float64 with the default JSONCodec.
+	src := fixedarr.New(0)
+	v := fixedarr.Derive(src, func(el interface{}) bool { return el.(float64) > 2 }, nil, 4)
+	src.Push(10.0)
+	if err := src.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{3.0, 4.0}; !reflect.DeepEqual(v.Value(), want) {
+		t.Fatalf("Value() = %v after UnmarshalBinary; want %v", v.Value(), want)
+	}
+}
+
+func TestDeriveClose(t *testing.T) {
+	src := fixedarr.New(4)
+	src.PushMany(1, 2)
+	v := fixedarr.Derive(src, nil, nil, 4)
+	v.Close()
+	v.Close() // closing twice is harmless
+	src.PushMany(3, 4)
+	src.Reset()
+	if want := []interface{}{1, 2}; !reflect.DeepEqual(v.Value(), want) {
+		t.Fatalf("Value() = %v after Close; want %v", v.Value(), want)
+	}
+}
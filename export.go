@@ -0,0 +1,347 @@
+package fixedarr
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// exportChunk is the number of elements the exports copy per lock.
+const exportChunk = 64
+
+// each calls fn with the elements, oldest first, and their index among the
+// visited ones. The elements are copied exportChunk at a time under the
+// lock, walking by sequence number, and fn runs without it: elements pushed
+// after each was called are not visited, and those evicted or removed
+// before their chunk is copied are skipped.
+func (a *Array) each(fn func(i int, el interface{}) error) error {
+	a.mu.RLock()
+	end := a.next
+	a.mu.RUnlock()
+
+	var last uint64 // sequence number of the last element copied
+	chunk := make([]interface{}, 0, exportChunk)
+	for n := 0; ; {
+		chunk = chunk[:0]
+		a.mu.RLock()
+		i, _ := a.index(Handle(last + 1))
+		for ; i < len(a.seqs) && a.seqs[i] <= end && len(chunk) < exportChunk; i++ {
+			chunk = append(chunk, a.array[i])
+			last = a.seqs[i]
+		}
+		a.mu.RUnlock()
+
+		if len(chunk) == 0 {
+			return nil
+		}
+		for _, el := range chunk {
+			if err := fn(n, el); err != nil {
+				return err
+			}
+			n++
+		}
+	}
+}
+
+// WriteNDJSON writes the elements, oldest first, as newline-delimited JSON.
+// Elements are copied a chunk at a time and encoded as w accepts them, so a
+// slow w never blocks Push: the elements pushed once the write started are
+// not written, and those evicted before being reached are skipped.
+func (a *Array) WriteNDJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	return a.each(func(i int, el interface{}) error {
+		if err := enc.Encode(el); err != nil {
+			return fmt.Errorf("fixedarr: writing element %d: %w", i, err)
+		}
+		return nil
+	})
+}
+
+// ReadNDJSON returns a new Array with the provided maxSize and options,
+// filled with the newline-delimited JSON values read from r; if there are
+// more than maxSize values, only the last ones are kept. Values decode with
+// the Codec of the array when it is a JSONCodec, and with JSONCodec{}
+// otherwise.
+func ReadNDJSON(r io.Reader, maxSize int, opts ...Option) (*Array, error) {
+	a := New(maxSize, opts...)
+	c, ok := a.codec.(JSONCodec)
+	if !ok {
+		c = JSONCodec{}
+	}
+	dec := c.NewDecoder(r)
+	for i := 0; ; i++ {
+		el, err := dec.Decode()
+		if err == io.EOF {
+			return a, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fixedarr: reading element %d: %w", i, err)
+		}
+		a.Push(el)
+	}
+}
+
+// WriteCSV writes the elements, oldest first, as CSV records preceded by a
+// header. Elements MUST be structs, or pointers to structs, of a single
+// type; columns are their exported fields, named by the `csv` struct tag
+// (or the field name; "-" skips a field). Nothing is written for an empty
+// array. As for WriteNDJSON, a slow w never blocks Push, and the elements
+// written are those held when the write started and not evicted since.
+func (a *Array) WriteCSV(w io.Writer) error {
+	a.mu.RLock()
+	if len(a.array) == 0 {
+		a.mu.RUnlock()
+		return nil
+	}
+	typ := reflect.TypeOf(a.array[0])
+	a.mu.RUnlock()
+
+	cols, err := csvColumnsOf(typ)
+	if err != nil {
+		return err
+	}
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.name
+	}
+	return writeCSV(w, a, header, func(el interface{}) ([]string, error) {
+		if reflect.TypeOf(el) != typ {
+			return nil, fmt.Errorf("element of type %T, want %v", el, typ)
+		}
+		v := reflect.Indirect(reflect.ValueOf(el))
+		if !v.IsValid() {
+			return nil, errors.New("nil element")
+		}
+		record := make([]string, len(cols))
+		for i, c := range cols {
+			s, err := formatCSVField(v.Field(c.index))
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", c.name, err)
+			}
+			record[i] = s
+		}
+		return record, nil
+	})
+}
+
+// WriteCSVFunc writes the elements, oldest first, as the CSV records
+// returned by column, preceded by header if not nil. As for WriteNDJSON, a
+// slow w never blocks Push, and the elements written are those held when
+// the write started and not evicted since.
+func (a *Array) WriteCSVFunc(w io.Writer, header []string, column func(el interface{}) ([]string, error)) error {
+	return writeCSV(w, a, header, column)
+}
+
+func writeCSV(w io.Writer, a *Array, header []string, column func(el interface{}) ([]string, error)) error {
+	cw := csv.NewWriter(w)
+	if header != nil {
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+	}
+	err := a.each(func(i int, el interface{}) error {
+		record, err := column(el)
+		if err != nil {
+			return fmt.Errorf("fixedarr: writing element %d: %w", i, err)
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV returns a new Array with the provided maxSize and options, filled
+// with the CSV records read from r, as written by WriteCSV. newElem returns
+// a pointer to a fresh struct (e.g. func() interface{} { return new(Event) });
+// the header names the fields each column is decoded into, and the pointed
+// struct is pushed. If there are more than maxSize records, only the last
+// ones are kept.
+func ReadCSV(r io.Reader, maxSize int, newElem func() interface{}, opts ...Option) (*Array, error) {
+	typ := reflect.TypeOf(newElem())
+	if typ.Kind() != reflect.Ptr {
+		return nil, errors.New("fixedarr.ReadCSV: newElem must return a pointer to a struct")
+	}
+	cols, err := csvColumnsOf(typ)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]int, len(cols))
+	for _, c := range cols {
+		byName[c.name] = c.index
+	}
+
+	var fields []int
+	return readCSV(r, maxSize, opts, true, func(header []string) error {
+		fields = make([]int, len(header))
+		for i, name := range header {
+			index, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("unknown column %q", name)
+			}
+			fields[i] = index
+		}
+		return nil
+	}, func(record []string) (interface{}, error) {
+		p := newElem()
+		v := reflect.ValueOf(p).Elem()
+		for i, s := range record {
+			if err := parseCSVField(v.Field(fields[i]), s); err != nil {
+				return nil, fmt.Errorf("column %s: %w", columnName(cols, fields[i]), err)
+			}
+		}
+		return v.Interface(), nil
+	})
+}
+
+// ReadCSVFunc returns a new Array with the provided maxSize and options,
+// filled with the elements returned by column for every CSV record read
+// from r; if hasHeader, the first record is skipped. If there are more than
+// maxSize records, only the last ones are kept. Every record is a fresh
+// slice, so column can keep it.
+func ReadCSVFunc(r io.Reader, maxSize int, hasHeader bool, column func(record []string) (interface{}, error), opts ...Option) (*Array, error) {
+	var onHeader func([]string) error
+	if hasHeader {
+		onHeader = func([]string) error { return nil }
+	}
+	return readCSV(r, maxSize, opts, false, onHeader, column)
+}
+
+// readCSV reads the records of r into a new Array; with reuseRecord, the
+// records passed to onHeader and column share storage, and MUST NOT be
+// retained.
+func readCSV(r io.Reader, maxSize int, opts []Option, reuseRecord bool, onHeader func([]string) error, column func([]string) (interface{}, error)) (*Array, error) {
+	a := New(maxSize, opts...)
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = reuseRecord
+	for line := 1; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return a, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fixedarr: reading CSV: %w", err)
+		}
+		if onHeader != nil {
+			if err := onHeader(record); err != nil {
+				return nil, fmt.Errorf("fixedarr: CSV header: %w", err)
+			}
+			onHeader = nil
+			continue
+		}
+		el, err := column(record)
+		if err != nil {
+			return nil, fmt.Errorf("fixedarr: CSV record %d: %w", line, err)
+		}
+		a.Push(el)
+	}
+}
+
+type csvColumn struct {
+	name  string
+	index int
+}
+
+func columnName(cols []csvColumn, index int) string {
+	for _, c := range cols {
+		if c.index == index {
+			return c.name
+		}
+	}
+	return strconv.Itoa(index)
+}
+
+// csvColumnsOf returns the columns of a struct type, or of a pointer to one.
+func csvColumnsOf(typ reflect.Type) ([]csvColumn, error) {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("fixedarr: CSV needs struct elements, not %v", typ)
+	}
+	var cols []csvColumn
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Tag.Get("csv")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		cols = append(cols, csvColumn{name: name, index: i})
+	}
+	return cols, nil
+}
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func formatCSVField(v reflect.Value) (string, error) {
+	if v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported type %v", v.Type())
+}
+
+func parseCSVField(v reflect.Value, s string) error {
+	if v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
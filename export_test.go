@@ -0,0 +1,192 @@
+package fixedarr_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+type event struct {
+	At      time.Time `csv:"at"`
+	Name    string    `csv:"name"`
+	Count   int       `csv:"count"`
+	Ratio   float64
+	Skipped string `csv:"-"`
+}
+
+func TestNDJSONRoundTrip(t *testing.T) {
+	a := fixedarr.New(3)
+	a.PushMany("a", 1.5, map[string]interface{}{"k": "v"}, []interface{}{true, nil})
+
+	var buf bytes.Buffer
+	if err := a.WriteNDJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "1.5\n{\"k\":\"v\"}\n[true,null]\n"; got != want {
+		t.Fatalf("WriteNDJSON wrote %q; want %q", got, want)
+	}
+
+	b, err := fixedarr.ReadNDJSON(&buf, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{map[string]interface{}{"k": "v"}, []interface{}{true, nil}}
+	if got := b.Value(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadNDJSON = %v; want %v", got, want)
+	}
+
+	if _, err := fixedarr.ReadNDJSON(strings.NewReader("1\n{"), 2); err == nil {
+		t.Fatal("ReadNDJSON accepted a truncated value")
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := fixedarr.New(2)
+	a.PushMany(
+		event{At: at, Name: "a", Count: 1, Ratio: 0.5, Skipped: "x"},
+		event{At: at.Add(time.Second), Name: "b,\"c\"", Count: -2, Ratio: 1e-9},
+	)
+
+	var buf bytes.Buffer
+	if err := a.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "at,name,count,Ratio\n" +
+		"2026-01-02T03:04:05Z,a,1,0.5\n" +
+		"2026-01-02T03:04:06Z,\"b,\"\"c\"\"\",-2,1e-09\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("WriteCSV wrote %q; want %q", got, want)
+	}
+
+	b, err := fixedarr.ReadCSV(&buf, 2, func() interface{} { return new(event) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := b.Value()
+	if len(got) != 2 {
+		t.Fatalf("ReadCSV = %v", got)
+	}
+	if e := got[1].(event); !e.At.Equal(at.Add(time.Second)) || e.Name != "b,\"c\"" || e.Count != -2 || e.Ratio != 1e-9 {
+		t.Fatalf("ReadCSV element = %+v", e)
+	}
+	if e := got[0].(event); e.Skipped != "" {
+		t.Fatalf("ReadCSV decoded a skipped field: %+v", e)
+	}
+
+	if _, err := fixedarr.ReadCSV(strings.NewReader("nope\nx\n"), 2, func() interface{} { return new(event) }); err == nil {
+		t.Fatal("ReadCSV accepted an unknown column")
+	}
+}
+
+func TestCSVFuncRoundTrip(t *testing.T) {
+	a := fixedarr.New(3)
+	a.PushMany([]string{"a", "b"}, []string{"c", "d"})
+
+	var buf bytes.Buffer
+	err := a.WriteCSVFunc(&buf, []string{"x", "y"}, func(el interface{}) ([]string, error) {
+		return el.([]string), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "x,y\na,b\nc,d\n"; got != want {
+		t.Fatalf("WriteCSVFunc wrote %q; want %q", got, want)
+	}
+
+	// The column func keeps the records it is passed.
+	b, err := fixedarr.ReadCSVFunc(&buf, 3, true, func(record []string) (interface{}, error) {
+		return record, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{[]string{"a", "b"}, []string{"c", "d"}}
+	if got := b.Value(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadCSVFunc = %v; want %v", got, want)
+	}
+
+	errColumn := errors.New("bad record")
+	_, err = fixedarr.ReadCSVFunc(strings.NewReader("a\n"), 3, false, func([]string) (interface{}, error) {
+		return nil, errColumn
+	})
+	if !errors.Is(err, errColumn) {
+		t.Fatalf("ReadCSVFunc error = %v; want %v", err, errColumn)
+	}
+}
+
+// blockingWriter blocks every write until release is closed.
+type blockingWriter struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	select {
+	case <-w.started:
+	default:
+		close(w.started)
+	}
+	<-w.release
+	return len(p), nil
+}
+
+func TestWriteNDJSONDoesNotBlockPush(t *testing.T) {
+	a := fixedarr.New(2)
+	a.Push(1)
+	w := &blockingWriter{started: make(chan struct{}), release: make(chan struct{})}
+	done := make(chan error)
+	go func() { done <- a.WriteNDJSON(w) }()
+
+	<-w.started
+	a.Push(2) // would deadlock if the writer held the lock
+	close(w.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// hookWriter calls hook before its first write.
+type hookWriter struct {
+	bytes.Buffer
+	hook func()
+}
+
+func (w *hookWriter) Write(p []byte) (int, error) {
+	if w.hook != nil {
+		w.hook()
+		w.hook = nil
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestWriteNDJSONStreams(t *testing.T) {
+	a := fixedarr.New(200)
+	for i := 0; i < 150; i++ {
+		a.Push(i)
+	}
+	// Once the write starts, the elements not yet copied are evicted by
+	// new ones, which were pushed after the write started: only the first
+	// chunk is written.
+	w := &hookWriter{hook: func() {
+		for i := 150; i < 350; i++ {
+			a.Push(i)
+		}
+	}}
+	if err := a.WriteNDJSON(w); err != nil {
+		t.Fatal(err)
+	}
+	var want strings.Builder
+	for i := 0; i < 64; i++ {
+		fmt.Fprintln(&want, i)
+	}
+	if got := w.String(); got != want.String() {
+		t.Fatalf("WriteNDJSON wrote %q; want %q", got, want.String())
+	}
+}
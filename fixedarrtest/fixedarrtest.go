@@ -0,0 +1,342 @@
+// Package fixedarrtest implements a model-based conformance harness for
+// implementations of the fixedarr array API.
+//
+// Random sequences of operations are applied both to the implementation
+// under test and to a trivially correct reference Model, and the two are
+// compared after every step. The harness can be driven from native Go
+// fuzzing (see Fuzz) or from table tests (see Run and Check).
+package fixedarrtest
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// Array is the API exercised by the harness; *fixedarr.Array satisfies it.
+type Array interface {
+	Push(el interface{})
+	Len() int
+	Max() int
+	Value() []interface{}
+	Reset()
+	GetAndReset() []interface{}
+}
+
+// PushManyer is implemented by arrays that can push several elements at once.
+type PushManyer interface {
+	PushMany(els ...interface{})
+}
+
+// Popper is implemented by arrays that can remove their oldest element.
+type Popper interface {
+	Pop() (interface{}, bool)
+}
+
+// MaxSetter is implemented by arrays whose limit size can be changed.
+type MaxSetter interface {
+	SetMax(maxSize int)
+}
+
+// OpKind identifies an operation applied by the harness.
+type OpKind uint8
+
+// The operations known to the harness.
+const (
+	OpPush OpKind = iota
+	OpPushMany
+	OpPop
+	OpReset
+	OpGetAndReset
+	OpSetMax
+	numOps
+)
+
+func (k OpKind) String() string {
+	switch k {
+	case OpPush:
+		return "Push"
+	case OpPushMany:
+		return "PushMany"
+	case OpPop:
+		return "Pop"
+	case OpReset:
+		return "Reset"
+	case OpGetAndReset:
+		return "GetAndReset"
+	case OpSetMax:
+		return "SetMax"
+	}
+	return fmt.Sprintf("OpKind(%d)", uint8(k))
+}
+
+// Op is a single operation; Values holds the pushed elements for OpPush
+// (one element) and OpPushMany, and N holds the new limit for OpSetMax.
+type Op struct {
+	Kind   OpKind
+	Values []int
+	N      int
+}
+
+func (op Op) String() string {
+	switch op.Kind {
+	case OpPush, OpPushMany:
+		return fmt.Sprintf("%s%v", op.Kind, op.Values)
+	case OpSetMax:
+		return fmt.Sprintf("%s(%d)", op.Kind, op.N)
+	}
+	return op.Kind.String() + "()"
+}
+
+// maxLimit bounds the limit sizes generated by the harness, so that
+// eviction is exercised often.
+const maxLimit = 16
+
+// Ops decodes a sequence of operations from arbitrary bytes, as produced
+// by the fuzzing engine. Every input decodes to a valid sequence.
+func Ops(data []byte) []Op {
+	var ops []Op
+	next := 0
+	for len(data) > 0 {
+		b := data[0]
+		data = data[1:]
+		op := Op{Kind: OpKind(b % uint8(numOps))}
+		switch op.Kind {
+		case OpPush:
+			op.Values = []int{next}
+			next++
+		case OpPushMany:
+			n := int(b>>4) % 5
+			for i := 0; i < n; i++ {
+				op.Values = append(op.Values, next)
+				next++
+			}
+		case OpSetMax:
+			op.N = 1 + int(b>>3)%maxLimit
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// RandomOps returns n random operations drawn from r, weighted towards
+// pushes so that arrays regularly reach their limit.
+func RandomOps(r *rand.Rand, n int) []Op {
+	ops := make([]Op, 0, n)
+	next := 0
+	for i := 0; i < n; i++ {
+		var op Op
+		switch p := r.Intn(100); {
+		case p < 60:
+			op = Op{Kind: OpPush, Values: []int{next}}
+			next++
+		case p < 75:
+			op = Op{Kind: OpPushMany}
+			for j := r.Intn(5); j > 0; j-- {
+				op.Values = append(op.Values, next)
+				next++
+			}
+		case p < 85:
+			op = Op{Kind: OpPop}
+		case p < 90:
+			op = Op{Kind: OpReset}
+		case p < 95:
+			op = Op{Kind: OpGetAndReset}
+		default:
+			op = Op{Kind: OpSetMax, N: 1 + r.Intn(maxLimit)}
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// Model is the reference implementation the harness compares against.
+type Model struct {
+	els     []interface{}
+	maxSize int
+}
+
+// NewModel returns an empty Model with the provided limit size.
+func NewModel(maxSize int) *Model {
+	return &Model{maxSize: maxSize}
+}
+
+// Push appends el, dropping the oldest element if over the limit.
+func (m *Model) Push(el interface{}) {
+	m.els = append(m.els, el)
+	m.trim()
+}
+
+// PushMany pushes every element in order.
+func (m *Model) PushMany(els ...interface{}) {
+	for _, el := range els {
+		m.Push(el)
+	}
+}
+
+// Pop removes and returns the oldest element.
+func (m *Model) Pop() (interface{}, bool) {
+	if len(m.els) == 0 {
+		return nil, false
+	}
+	el := m.els[0]
+	m.els = m.els[1:]
+	return el, true
+}
+
+// Len returns the number of elements.
+func (m *Model) Len() int { return len(m.els) }
+
+// Max returns the limit size.
+func (m *Model) Max() int { return m.maxSize }
+
+// Value returns the elements, oldest first.
+func (m *Model) Value() []interface{} { return m.els }
+
+// Reset removes every element.
+func (m *Model) Reset() { m.els = nil }
+
+// GetAndReset returns the elements and removes them.
+func (m *Model) GetAndReset() []interface{} {
+	els := m.els
+	m.els = nil
+	return els
+}
+
+// SetMax changes the limit size, dropping the oldest elements if needed.
+func (m *Model) SetMax(maxSize int) {
+	m.maxSize = maxSize
+	m.trim()
+}
+
+func (m *Model) trim() {
+	if over := len(m.els) - m.maxSize; over > 0 {
+		m.els = m.els[over:]
+	}
+}
+
+// Check applies ops to a fresh array obtained from newArray and to a Model,
+// and returns an error describing the first divergence, if any.
+// Operations the array does not implement are skipped for both.
+func Check(newArray func(maxSize int) Array, maxSize int, ops []Op) error {
+	arr := newArray(maxSize)
+	model := NewModel(maxSize)
+	if err := compare(arr, model); err != nil {
+		return fmt.Errorf("after New(%d): %v", maxSize, err)
+	}
+	for i, op := range ops {
+		if err := apply(arr, model, op); err != nil {
+			return fmt.Errorf("op %d %s: %v", i, op, err)
+		}
+		if err := compare(arr, model); err != nil {
+			return fmt.Errorf("after op %d %s: %v", i, op, err)
+		}
+	}
+	return nil
+}
+
+func apply(arr Array, model *Model, op Op) error {
+	switch op.Kind {
+	case OpPush:
+		for _, v := range op.Values {
+			arr.Push(v)
+			model.Push(v)
+		}
+	case OpPushMany:
+		pm, ok := arr.(PushManyer)
+		if !ok {
+			return nil
+		}
+		els := make([]interface{}, len(op.Values))
+		for i, v := range op.Values {
+			els[i] = v
+		}
+		pm.PushMany(els...)
+		model.PushMany(els...)
+	case OpPop:
+		p, ok := arr.(Popper)
+		if !ok {
+			return nil
+		}
+		got, gotOK := p.Pop()
+		want, wantOK := model.Pop()
+		if got != want || gotOK != wantOK {
+			return fmt.Errorf("Pop() = %v, %v; want %v, %v", got, gotOK, want, wantOK)
+		}
+	case OpReset:
+		arr.Reset()
+		model.Reset()
+	case OpGetAndReset:
+		got := arr.GetAndReset()
+		want := model.GetAndReset()
+		if err := equal(got, want); err != nil {
+			return fmt.Errorf("GetAndReset(): %v", err)
+		}
+	case OpSetMax:
+		ms, ok := arr.(MaxSetter)
+		if !ok {
+			return nil
+		}
+		ms.SetMax(op.N)
+		model.SetMax(op.N)
+	}
+	return nil
+}
+
+func compare(arr Array, model *Model) error {
+	if got, want := arr.Max(), model.Max(); got != want {
+		return fmt.Errorf("Max() = %d; want %d", got, want)
+	}
+	if got, want := arr.Len(), model.Len(); got != want {
+		return fmt.Errorf("Len() = %d; want %d", got, want)
+	}
+	if err := equal(arr.Value(), model.Value()); err != nil {
+		return fmt.Errorf("Value(): %v", err)
+	}
+	return nil
+}
+
+func equal(got, want []interface{}) error {
+	if len(got) != len(want) {
+		return fmt.Errorf("got %v; want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return fmt.Errorf("got %v; want %v", got, want)
+		}
+	}
+	return nil
+}
+
+// Run is like Check, but reports the divergence as a test failure.
+func Run(t testing.TB, newArray func(maxSize int) Array, maxSize int, ops []Op) {
+	t.Helper()
+	if err := Check(newArray, maxSize, ops); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Fuzz registers a fuzz target with f that checks the arrays returned by
+// newArray against the Model, seeding the corpus with a few interesting
+// sequences. Use it as the body of a FuzzXxx function:
+//
+//	func FuzzArray(f *testing.F) {
+//		fixedarrtest.Fuzz(f, func(n int) fixedarrtest.Array { return fixedarr.New(n) })
+//	}
+func Fuzz(f *testing.F, newArray func(maxSize int) Array) {
+	f.Helper()
+	f.Add(uint8(1), []byte{0, 0, 0, 2, 0})
+	f.Add(uint8(3), []byte{0, 0, 0, 0, 3, 0, 0, 0, 0})
+	f.Add(uint8(4), []byte{0x41, 0x31, 0, 2, 2, 4, 0, 0x25, 0})
+	r := rand.New(rand.NewSource(1))
+	seed := make([]byte, 64)
+	r.Read(seed)
+	f.Add(uint8(8), seed)
+
+	f.Fuzz(func(t *testing.T, size uint8, data []byte) {
+		maxSize := 1 + int(size)%maxLimit
+		if err := Check(newArray, maxSize, Ops(data)); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
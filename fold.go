@@ -0,0 +1,22 @@
+package fixedarr
+
+// WithFold makes the array fold every evicted element into an accumulator,
+// starting from init, so that their contribution is summarized rather than
+// lost; see Folded. fn MUST NOT retain or mutate init, which is restored by
+// Reset.
+func WithFold(init interface{}, fn func(acc, evicted interface{}) interface{}) Option {
+	return func(a *Array) {
+		a.foldInit = init
+		a.foldFn = fn
+		a.folded = init
+	}
+}
+
+// Folded returns the accumulator of the elements evicted since the array
+// was created or last reset, or nil if the array has no fold.
+func (a *Array) Folded() interface{} {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.folded
+}
@@ -0,0 +1,63 @@
+package fixedarr_test
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+func sum(acc, evicted interface{}) interface{} {
+	return acc.(int) + evicted.(int)
+}
+
+func TestFold(t *testing.T) {
+	a := fixedarr.New(3, fixedarr.WithFold(0, sum))
+	if got := a.Folded(); got != 0 {
+		t.Fatalf("Folded() = %v; want init 0", got)
+	}
+
+	// Push folds the elements it evicts.
+	a.PushMany(1, 2, 3, 4, 5)
+	if got := a.Folded(); got != 3 {
+		t.Fatalf("Folded() = %v after pushes; want 1+2", got)
+	}
+
+	// So does SetMax.
+	a.SetMax(1)
+	if got := a.Folded(); got != 10 {
+		t.Fatalf("Folded() = %v after SetMax; want 1+2+3+4", got)
+	}
+
+	// Popped and removed elements are not folded.
+	a.SetMax(3)
+	h := a.PushHandle(6)
+	a.Push(7)
+	a.Pop()
+	a.Remove(h)
+	if got, n := a.Folded(), a.Len(); got != 10 || n != 1 {
+		t.Fatalf("Folded() = %v, Len() = %d after Pop and Remove; want 10, 1", got, n)
+	}
+
+	a.Reset()
+	if got := a.Folded(); got != 0 {
+		t.Fatalf("Folded() = %v after Reset; want init 0", got)
+	}
+}
+
+func TestFoldBudget(t *testing.T) {
+	b := fixedarr.NewBudget(3, nil)
+	a := fixedarr.New(10, fixedarr.WithFold(0, sum))
+	b.Join(a, 0)
+	a.PushMany(1, 2, 3, 4, 5)
+	if got := a.Folded(); got != 3 {
+		t.Fatalf("Folded() = %v after Budget evictions; want 1+2", got)
+	}
+}
+
+func TestFoldNone(t *testing.T) {
+	a := fixedarr.New(1)
+	a.PushMany(1, 2)
+	if got := a.Folded(); got != nil {
+		t.Fatalf("Folded() = %v without a fold; want nil", got)
+	}
+}
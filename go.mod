@@ -0,0 +1,3 @@
+module github.com/gagliardetto/fixedarr
+
+go 1.24
@@ -0,0 +1,56 @@
+package fixedarr
+
+import "sort"
+
+// Handle refers to an element pushed with PushHandle. It is the sequence
+// number of the element, so it is cheap to keep and never dangles: once
+// the element is evicted or removed, the handle just reports it as gone.
+// The zero Handle refers to no element.
+type Handle uint64
+
+// PushHandle pushes el like Push does, and returns a Handle to it; with
+// WithCoalesce, that is a Handle to the Coalesced element counting it. The
+// zero Handle is returned if el was not admitted (see WithSampling).
+func (a *Array) PushHandle(el interface{}) Handle {
+	return a.add(el)
+}
+
+// index returns the index of the element referred to by h; a.mu MUST be held.
+func (a *Array) index(h Handle) (int, bool) {
+	seq := uint64(h)
+	i := sort.Search(len(a.seqs), func(i int) bool { return a.seqs[i] >= seq })
+	return i, h != 0 && i < len(a.seqs) && a.seqs[i] == seq
+}
+
+// Get returns the element referred to by h, and false if it is no longer
+// retained.
+func (a *Array) Get(h Handle) (interface{}, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	i, ok := a.index(h)
+	if !ok {
+		return nil, false
+	}
+	return a.array[i], true
+}
+
+// Remove removes the element referred to by h, and reports whether it was
+// still retained; removed elements are not folded (see WithFold).
+func (a *Array) Remove(h Handle) bool {
+	a.mu.Lock()
+	i, ok := a.index(h)
+	if !ok {
+		a.mu.Unlock()
+		return false
+	}
+	el := a.removeAt(i)
+	a.atCapacity = false
+	budget := a.budget
+	a.mu.Unlock()
+
+	if budget != nil {
+		budget.adjust(-budget.weigh(el))
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package fixedarr_test
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+func TestHandle(t *testing.T) {
+	a := fixedarr.New(2)
+	h1 := a.PushHandle("a")
+	h2 := a.PushHandle("b")
+	if v, ok := a.Get(h1); !ok || v != "a" {
+		t.Fatalf("Get(h1) = %v, %v; want a, true", v, ok)
+	}
+
+	a.Push("c") // evicts "a"
+	if v, ok := a.Get(h1); ok {
+		t.Fatalf("Get(h1) = %v after eviction", v)
+	}
+	if v, ok := a.Get(h2); !ok || v != "b" {
+		t.Fatalf("Get(h2) = %v, %v; want b, true", v, ok)
+	}
+
+	if !a.Remove(h2) {
+		t.Fatal("Remove(h2) = false")
+	}
+	if a.Remove(h2) || a.Remove(h1) {
+		t.Fatal("Remove of a gone element = true")
+	}
+	if a.Len() != 1 {
+		t.Fatalf("Len() = %d after Remove; want 1", a.Len())
+	}
+
+	// Remove makes room: pushing does not evict.
+	h4 := a.PushHandle("d")
+	if a.Len() != 2 {
+		t.Fatalf("Len() = %d; want 2", a.Len())
+	}
+
+	a.Reset()
+	if _, ok := a.Get(h4); ok {
+		t.Fatal("Get(h4) found an element after Reset")
+	}
+	h5 := a.PushHandle("e")
+	if h5 == h4 || h5 == 0 {
+		t.Fatalf("handle %d reused after Reset", h5)
+	}
+	if _, ok := a.Get(0); ok {
+		t.Fatal("Get(0) found an element")
+	}
+}
+
+func TestHandleCoalesce(t *testing.T) {
+	a := fixedarr.New(3, fixedarr.WithCoalesce(same))
+	h1, h2 := a.PushHandle("a"), a.PushHandle("a")
+	if h1 != h2 {
+		t.Fatalf("coalesced pushes returned handles %d and %d", h1, h2)
+	}
+	v, ok := a.Get(h1)
+	if c, _ := v.(fixedarr.Coalesced); !ok || c.Count != 2 {
+		t.Fatalf("Get(h1) = %v, %v", v, ok)
+	}
+}
+
+func TestHandleAfterUnmarshal(t *testing.T) {
+	src := fixedarr.New(4)
+	src.PushMany("x", "y")
+	data, err := src.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new array keeps the saved sequence numbers, so handles to src
+	// resolve in the copy.
+	h := src.PushHandle("z")
+	src.Pop()
+	data2, _ := src.MarshalBinary()
+	b := fixedarr.New(0)
+	if err := b.UnmarshalBinary(data2); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := b.Get(h); !ok || v != "z" {
+		t.Fatalf("Get(h) = %v, %v in the copy; want z, true", v, ok)
+	}
+
+	// An array restoring an older snapshot never resolves its own
+	// handles to restored elements.
+	a := fixedarr.New(4)
+	handles := []fixedarr.Handle{a.PushHandle(1), a.PushHandle(2), a.PushHandle(3)}
+	if err := a.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range handles {
+		if v, ok := a.Get(h); ok {
+			t.Fatalf("Get(%d) = %v after restoring another snapshot", h, v)
+		}
+	}
+	if h := a.PushHandle("w"); h <= handles[2] {
+		t.Fatalf("handle %d not newer than %d", h, handles[2])
+	}
+}
@@ -0,0 +1,65 @@
+package fixedarr
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Latest holds a single value, the latest one set, with a version number
+// incremented at every Set; readers never block, and can wait for a value
+// newer than the one they have. It replaces the New(1) idiom.
+type Latest[T any] struct {
+	state atomic.Pointer[latestState[T]]
+}
+
+type latestState[T any] struct {
+	value   T
+	version uint64
+	newer   chan struct{} // closed when superseded
+}
+
+// NewLatest returns a new Latest holding the zero value of T at version 0.
+func NewLatest[T any]() *Latest[T] {
+	l := &Latest[T]{}
+	l.state.Store(&latestState[T]{newer: make(chan struct{})})
+	return l
+}
+
+// Set stores v, and returns its version.
+func (l *Latest[T]) Set(v T) uint64 {
+	for {
+		old := l.state.Load()
+		s := &latestState[T]{
+			value:   v,
+			version: old.version + 1,
+			newer:   make(chan struct{}),
+		}
+		if l.state.CompareAndSwap(old, s) {
+			close(old.newer)
+			return s.version
+		}
+	}
+}
+
+// Get returns the latest value, and its version.
+func (l *Latest[T]) Get() (T, uint64) {
+	s := l.state.Load()
+	return s.value, s.version
+}
+
+// WaitNewer returns the latest value and its version as soon as the version
+// is greater than version, or the error of ctx if it is done first.
+func (l *Latest[T]) WaitNewer(ctx context.Context, version uint64) (T, uint64, error) {
+	for {
+		s := l.state.Load()
+		if s.version > version {
+			return s.value, s.version, nil
+		}
+		select {
+		case <-s.newer:
+		case <-ctx.Done():
+			var zero T
+			return zero, s.version, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package fixedarr_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+func TestLatest(t *testing.T) {
+	l := fixedarr.NewLatest[string]()
+	if v, version := l.Get(); v != "" || version != 0 {
+		t.Fatalf("Get() = %q, %d; want \"\", 0", v, version)
+	}
+	if version := l.Set("a"); version != 1 {
+		t.Fatalf("Set() = %d; want 1", version)
+	}
+	if version := l.Set("b"); version != 2 {
+		t.Fatalf("Set() = %d; want 2", version)
+	}
+	if v, version := l.Get(); v != "b" || version != 2 {
+		t.Fatalf("Get() = %q, %d; want \"b\", 2", v, version)
+	}
+
+	// A reader behind gets the latest value at once.
+	v, version, err := l.WaitNewer(context.Background(), 0)
+	if err != nil || v != "b" || version != 2 {
+		t.Fatalf("WaitNewer(0) = %q, %d, %v; want \"b\", 2, nil", v, version, err)
+	}
+}
+
+func TestLatestWaitNewer(t *testing.T) {
+	l := fixedarr.NewLatest[int]()
+	l.Set(1)
+
+	type result struct {
+		v, version uint64
+		err        error
+	}
+	done := make(chan result)
+	go func() {
+		v, version, err := l.WaitNewer(context.Background(), 1)
+		done <- result{uint64(v), version, err}
+	}()
+	select {
+	case r := <-done:
+		t.Fatalf("WaitNewer(1) returned %+v before a Set", r)
+	case <-time.After(10 * time.Millisecond):
+	}
+	l.Set(2)
+	if r := <-done; r.err != nil || r.v != 2 || r.version != 2 {
+		t.Fatalf("WaitNewer(1) = %+v; want 2, 2, nil", r)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_, version, err := l.WaitNewer(ctx, 2)
+		done <- result{0, version, err}
+	}()
+	cancel()
+	if r := <-done; !errors.Is(r.err, context.Canceled) || r.version != 2 {
+		t.Fatalf("WaitNewer after cancel = %+v; want version 2, context.Canceled", r)
+	}
+}
+
+func TestLatestConcurrentSet(t *testing.T) {
+	const writers, sets = 8, 1000
+	l := fixedarr.NewLatest[int]()
+
+	// A reader following along sees versions only go up.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	readerDone := make(chan error)
+	go func() {
+		var version uint64
+		for version < writers*sets {
+			_, v, err := l.WaitNewer(ctx, version)
+			if err != nil {
+				readerDone <- err
+				return
+			}
+			if v <= version {
+				readerDone <- errors.New("version went backwards")
+				return
+			}
+			version = v
+		}
+		readerDone <- nil
+	}()
+
+	var wg sync.WaitGroup
+	versions := make([][]uint64, writers)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < sets; i++ {
+				versions[w] = append(versions[w], l.Set(w))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// Every Set got its own version.
+	seen := make(map[uint64]bool)
+	for _, vs := range versions {
+		for _, v := range vs {
+			if seen[v] {
+				t.Fatalf("version %d returned twice", v)
+			}
+			seen[v] = true
+		}
+	}
+	if _, version := l.Get(); version != writers*sets || len(seen) != writers*sets {
+		t.Fatalf("version = %d with %d distinct; want %d", version, len(seen), writers*sets)
+	}
+	if err := <-readerDone; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,104 @@
+package fixedarr
+
+import "sync/atomic"
+
+// LossyChan is a channel pair backed by a fixed size array: sends on In
+// never wait for receivers, and when the array is full the oldest element
+// is dropped to make room. Out delivers the retained elements, oldest
+// first, and can be used in a select along other channels.
+//
+// Closing In closes Out once every retained element has been received;
+// until then, a goroutine stays blocked on Out.
+type LossyChan[T any] struct {
+	in      chan T
+	out     chan T
+	arr     *Array
+	dropped atomic.Uint64
+}
+
+// NewLossyChan returns a new LossyChan retaining up to maxSize elements;
+// maxSize MUST be a positive number.
+func NewLossyChan[T any](maxSize int) *LossyChan[T] {
+	if maxSize < 1 {
+		panic("fixedarr.NewLossyChan: maxSize must be positive")
+	}
+	c := &LossyChan[T]{
+		in:  make(chan T),
+		out: make(chan T),
+		arr: New(maxSize),
+	}
+	go c.pump()
+	return c
+}
+
+// In returns the channel to send elements on; close it when done.
+func (c *LossyChan[T]) In() chan<- T {
+	return c.in
+}
+
+// Out returns the channel to receive elements from.
+func (c *LossyChan[T]) Out() <-chan T {
+	return c.out
+}
+
+// Len returns the number of elements waiting to be received.
+func (c *LossyChan[T]) Len() int {
+	return c.arr.Len()
+}
+
+// Dropped returns the number of elements dropped so far.
+func (c *LossyChan[T]) Dropped() uint64 {
+	return c.dropped.Load()
+}
+
+func (c *LossyChan[T]) pump() {
+	defer close(c.out)
+
+	for {
+		var out chan T
+		var next T
+		if el, ok := c.peek(); ok {
+			out, next = c.out, el
+		}
+		select {
+		case el, ok := <-c.in:
+			if !ok {
+				c.drain()
+				return
+			}
+			c.arr.mu.Lock()
+			_, evicted := c.arr.push(el)
+			c.arr.mu.Unlock()
+			if evicted {
+				c.dropped.Add(1)
+			}
+		case out <- next:
+			c.arr.Pop()
+		}
+	}
+}
+
+// peek returns the oldest retained element.
+func (c *LossyChan[T]) peek() (T, bool) {
+	c.arr.mu.RLock()
+	defer c.arr.mu.RUnlock()
+
+	if len(c.arr.array) == 0 {
+		var zero T
+		return zero, false
+	}
+	el, _ := c.arr.array[0].(T) // a nil interface T is stored as nil
+	return el, true
+}
+
+// drain delivers the retained elements once In is closed.
+func (c *LossyChan[T]) drain() {
+	for {
+		el, ok := c.arr.Pop()
+		if !ok {
+			return
+		}
+		v, _ := el.(T)
+		c.out <- v
+	}
+}
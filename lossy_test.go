@@ -0,0 +1,83 @@
+package fixedarr_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+// waitDropped waits until c has dropped n elements.
+func waitDropped[T any](t *testing.T, c *fixedarr.LossyChan[T], n uint64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Dropped() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Dropped() = %d; want %d", c.Dropped(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLossyChanOverflow(t *testing.T) {
+	c := fixedarr.NewLossyChan[int](3)
+	for i := 0; i < 10; i++ {
+		c.In() <- i // never blocks on the receiver
+	}
+	waitDropped(t, c, 7)
+	if c.Len() != 3 {
+		t.Fatalf("Len() = %d; want 3", c.Len())
+	}
+	for want := 7; want < 10; want++ {
+		if got := <-c.Out(); got != want {
+			t.Fatalf("received %d; want %d", got, want)
+		}
+	}
+	select {
+	case v := <-c.Out():
+		t.Fatalf("received %d from an empty channel", v)
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(c.In())
+	if _, ok := <-c.Out(); ok {
+		t.Fatal("Out is not closed")
+	}
+}
+
+func TestLossyChanCloseDrains(t *testing.T) {
+	c := fixedarr.NewLossyChan[string](2)
+	c.In() <- "a"
+	c.In() <- "b"
+	c.In() <- "c"
+	close(c.In())
+
+	var got []string
+	for v := range c.Out() {
+		got = append(got, v)
+	}
+	if want := []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("received %v; want %v", got, want)
+	}
+	if c.Dropped() != 1 || c.Len() != 0 {
+		t.Fatalf("Dropped() = %d, Len() = %d; want 1, 0", c.Dropped(), c.Len())
+	}
+}
+
+func TestLossyChanNilInterface(t *testing.T) {
+	c := fixedarr.NewLossyChan[error](2)
+	errFoo := errors.New("foo")
+	c.In() <- nil
+	c.In() <- errFoo
+	if err := <-c.Out(); err != nil {
+		t.Fatalf("received %v; want nil", err)
+	}
+	close(c.In())
+	if err := <-c.Out(); err != errFoo {
+		t.Fatalf("received %v; want %v", err, errFoo)
+	}
+	if _, ok := <-c.Out(); ok {
+		t.Fatal("Out is not closed")
+	}
+}
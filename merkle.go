@@ -0,0 +1,272 @@
+package fixedarr
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+)
+
+// MerkleArray is a fixed size array maintaining a Merkle tree over its
+// elements, so that replicas of the same buffer can be compared cheaply.
+// Every element has a sequence number, and the array retains the elements
+// among the last maxSize sequence numbers. Elements are grouped in blocks
+// of blockSize consecutive sequence numbers; the leaves of the tree are the
+// block hashes, laid out as a ring. Replicas compare Root, and if it
+// differs exchange Blocks, and then the contents of the blocks listed by
+// DiffBlocks.
+//
+// Replicas fed with PushSeq, from sequence numbers they share, converge to
+// the same blocks whatever their history; Push numbers elements locally, so
+// it only suits replicas fed with the same elements from the start.
+type MerkleArray struct {
+	mu        *sync.RWMutex
+	arr       *Array
+	codec     Codec
+	blockSize uint64
+	nodes     [][sha256.Size]byte // nodes[1] is the root, leaves start at len(nodes)/2
+}
+
+type merkleEntry struct {
+	value interface{}
+	hash  [sha256.Size]byte
+}
+
+// MerkleBlock is the hash of a block of elements; the block holds the
+// retained elements with a sequence number in [First, First+blockSize).
+type MerkleBlock struct {
+	Index uint64
+	First uint64
+	Hash  [sha256.Size]byte
+}
+
+// NewMerkleArray returns a new MerkleArray; elements are hashed by their
+// encoding with the Codec set by opts (DefaultCodec otherwise), which MUST
+// be deterministic. maxSize and blockSize MUST be positive numbers, and
+// opts MUST NOT include WithSampling, WithCoalesce or WithFold, which would
+// make replicas hold different elements.
+func NewMerkleArray(maxSize, blockSize int, opts ...Option) *MerkleArray {
+	if maxSize < 1 || blockSize < 1 {
+		panic("fixedarr.NewMerkleArray: maxSize and blockSize must be positive")
+	}
+	arr := New(maxSize, opts...)
+	if arr.sampler != nil || arr.coalesce != nil || arr.foldFn != nil {
+		panic("fixedarr.NewMerkleArray: sampling, coalescing and folding are not supported")
+	}
+	// A window of maxSize sequence numbers spans at most this many blocks.
+	blocks := (maxSize+blockSize-2)/blockSize + 1
+	leaves := 1
+	for leaves < blocks {
+		leaves *= 2
+	}
+	return &MerkleArray{
+		mu:        &sync.RWMutex{},
+		arr:       arr,
+		codec:     arr.codec,
+		blockSize: uint64(blockSize),
+		nodes:     make([][sha256.Size]byte, 2*leaves),
+	}
+}
+
+// Push encodes and appends v, with the sequence number following the last
+// one pushed (1 for the first element); if the array has reached its limit
+// capacity, the oldest element will be removed.
+func (m *MerkleArray) Push(v interface{}) error {
+	e, err := m.entry(v)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.push(m.arr.next+1, e)
+	return nil
+}
+
+// PushSeq encodes and appends v with the sequence number seq, which MUST be
+// positive and greater than the last one pushed; the elements whose
+// sequence number is no longer among the last maxSize ones are removed.
+func (m *MerkleArray) PushSeq(seq uint64, v interface{}) error {
+	e, err := m.entry(v)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if last := m.arr.next; seq == 0 || seq <= last {
+		return fmt.Errorf("fixedarr: sequence number %d does not follow %d", seq, last)
+	}
+	m.push(seq, e)
+	return nil
+}
+
+// entry returns the entry holding v, hashed by its encoding.
+func (m *MerkleArray) entry(v interface{}) (merkleEntry, error) {
+	data, err := m.codec.Encode(v)
+	if err != nil {
+		return merkleEntry{}, err
+	}
+	return merkleEntry{value: v, hash: sha256.Sum256(data)}, nil
+}
+
+// push appends e with the sequence number seq, removing the elements that
+// fall out of the window, and updates the tree; m.mu MUST be held.
+func (m *MerkleArray) push(seq uint64, e merkleEntry) {
+	a := m.arr
+	a.mu.Lock()
+	var stale []uint64 // blocks losing elements, oldest first
+	for len(a.seqs) > 0 && a.seqs[0]+uint64(a.maxSize) <= seq {
+		if index := a.seqs[0] / m.blockSize; len(stale) == 0 || stale[len(stale)-1] != index {
+			stale = append(stale, index)
+		}
+		a.removeOldest()
+	}
+	// The window leaves room for e: append it with its own sequence number
+	// rather than through Array.push, which numbers and evicts on its own.
+	a.next = seq
+	a.array = append(a.array, e)
+	a.seqs = append(a.seqs, seq)
+	a.mu.Unlock()
+
+	// A stale block may share its leaf with the block of e, which is then
+	// the only one retaining elements: update the latter last.
+	for _, index := range stale {
+		m.updateBlock(index)
+	}
+	m.updateBlock(seq / m.blockSize)
+}
+
+// updateBlock recomputes the hash of a block and the path from its leaf to
+// the root; m.mu MUST be held.
+func (m *MerkleArray) updateBlock(index uint64) {
+	leaves := uint64(len(m.nodes) / 2)
+	i := leaves + index%leaves
+	m.nodes[i] = m.blockHash(index)
+	var zero [sha256.Size]byte
+	for i /= 2; i >= 1; i /= 2 {
+		// Empty subtrees hash to zero, whatever their history.
+		if m.nodes[2*i] == zero && m.nodes[2*i+1] == zero {
+			m.nodes[i] = zero
+			continue
+		}
+		h := sha256.New()
+		h.Write(m.nodes[2*i][:])
+		h.Write(m.nodes[2*i+1][:])
+		h.Sum(m.nodes[i][:0])
+	}
+}
+
+// block returns the range of indexes of the retained elements of a block;
+// m.mu MUST be held.
+func (m *MerkleArray) block(index uint64) (int, int) {
+	a := m.arr
+	i, _ := a.index(Handle(index * m.blockSize))
+	j := i
+	for j < len(a.seqs) && a.seqs[j] < (index+1)*m.blockSize {
+		j++
+	}
+	return i, j
+}
+
+// blockHash returns the hash of the retained elements of a block, or all
+// zeroes if it has none; m.mu MUST be held.
+func (m *MerkleArray) blockHash(index uint64) [sha256.Size]byte {
+	var sum [sha256.Size]byte
+	i, j := m.block(index)
+	if i == j {
+		return sum
+	}
+	h := sha256.New()
+	for ; i < j; i++ {
+		fmt.Fprintf(h, "%d:", m.arr.seqs[i])
+		e := m.arr.array[i].(merkleEntry)
+		h.Write(e.hash[:])
+	}
+	h.Sum(sum[:0])
+	return sum
+}
+
+// Root returns the root hash of the tree; replicas holding the same
+// elements, with the same sequence numbers, have the same root.
+func (m *MerkleArray) Root() [sha256.Size]byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.nodes[1]
+}
+
+// Blocks returns the hashes of the blocks spanned by the retained elements,
+// oldest first.
+func (m *MerkleArray) Blocks() []MerkleBlock {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	seqs := m.arr.seqs
+	if len(seqs) == 0 {
+		return nil
+	}
+	leaves := uint64(len(m.nodes) / 2)
+	var blocks []MerkleBlock
+	for index := seqs[0] / m.blockSize; index <= seqs[len(seqs)-1]/m.blockSize; index++ {
+		blocks = append(blocks, MerkleBlock{
+			Index: index,
+			First: index * m.blockSize,
+			Hash:  m.nodes[leaves+index%leaves],
+		})
+	}
+	return blocks
+}
+
+// DiffBlocks returns the blocks of m that are missing from, or different
+// in, the blocks of another replica, as returned by its Blocks.
+func (m *MerkleArray) DiffBlocks(other []MerkleBlock) []MerkleBlock {
+	theirs := make(map[uint64][sha256.Size]byte, len(other))
+	for _, b := range other {
+		theirs[b.Index] = b.Hash
+	}
+	var diff []MerkleBlock
+	for _, b := range m.Blocks() {
+		if h, ok := theirs[b.Index]; !ok || h != b.Hash {
+			diff = append(diff, b)
+		}
+	}
+	return diff
+}
+
+// Block returns the retained elements of the block with the provided index,
+// oldest first.
+func (m *MerkleArray) Block(index uint64) []interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var els []interface{}
+	i, j := m.block(index)
+	for ; i < j; i++ {
+		els = append(els, m.arr.array[i].(merkleEntry).value)
+	}
+	return els
+}
+
+// Len returns the current length of the array
+func (m *MerkleArray) Len() int {
+	return m.arr.Len()
+}
+
+// Max returns the limit size of the array
+func (m *MerkleArray) Max() int {
+	return m.arr.Max()
+}
+
+// Value returns a copy of the current elements, oldest first.
+func (m *MerkleArray) Value() []interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	els := make([]interface{}, len(m.arr.array))
+	for i, el := range m.arr.array {
+		els[i] = el.(merkleEntry).value
+	}
+	return els
+}
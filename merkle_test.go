@@ -0,0 +1,148 @@
+package fixedarr_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+// pushSeqs pushes every seq of seqs to m, with the value seq*10.
+func pushSeqs(t *testing.T, m *fixedarr.MerkleArray, seqs ...uint64) {
+	t.Helper()
+	for _, seq := range seqs {
+		if err := m.PushSeq(seq, seq*10); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func seqRange(from, to uint64) []uint64 {
+	var seqs []uint64
+	for seq := from; seq <= to; seq++ {
+		seqs = append(seqs, seq)
+	}
+	return seqs
+}
+
+func TestMerkleConverges(t *testing.T) {
+	// Replicas with different histories, holding the same elements with the
+	// same sequence numbers, have the same tree.
+	a, b := fixedarr.NewMerkleArray(5, 2), fixedarr.NewMerkleArray(5, 2)
+	pushSeqs(t, a, seqRange(1, 23)...)
+	pushSeqs(t, b, seqRange(19, 23)...)
+	if !reflect.DeepEqual(a.Value(), b.Value()) {
+		t.Fatalf("Value() = %v, %v; want equal", a.Value(), b.Value())
+	}
+	if a.Root() != b.Root() {
+		t.Fatal("roots differ")
+	}
+	if !reflect.DeepEqual(a.Blocks(), b.Blocks()) {
+		t.Fatalf("Blocks() = %v, %v; want equal", a.Blocks(), b.Blocks())
+	}
+
+	// Push numbers elements from 1.
+	c := fixedarr.NewMerkleArray(5, 2)
+	for seq := uint64(1); seq <= 23; seq++ {
+		if err := c.Push(seq * 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.Root() != a.Root() {
+		t.Fatal("Push and PushSeq from 1 give different roots")
+	}
+}
+
+func TestMerkleDiff(t *testing.T) {
+	a, b := fixedarr.NewMerkleArray(5, 2), fixedarr.NewMerkleArray(5, 2)
+	pushSeqs(t, a, seqRange(1, 23)...)
+	pushSeqs(t, b, seqRange(1, 20)...)
+	b.PushSeq(21, "other")
+	pushSeqs(t, b, 22, 23)
+	if a.Root() == b.Root() {
+		t.Fatal("roots are equal")
+	}
+
+	diff := a.DiffBlocks(b.Blocks())
+	if len(diff) != 1 || diff[0].Index != 10 || diff[0].First != 20 {
+		t.Fatalf("DiffBlocks() = %v; want block 10", diff)
+	}
+	if got, want := a.Block(10), []interface{}{uint64(200), uint64(210)}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Block(10) = %v; want %v", got, want)
+	}
+	// Block 9 only retains seq 19.
+	if got, want := a.Block(9), []interface{}{uint64(190)}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Block(9) = %v; want %v", got, want)
+	}
+}
+
+func TestMerkleWindow(t *testing.T) {
+	m := fixedarr.NewMerkleArray(5, 2)
+	var zero [32]byte
+	if m.Root() != zero || m.Blocks() != nil {
+		t.Fatal("empty array has a root or blocks")
+	}
+
+	// The array retains the elements among the last 5 sequence numbers.
+	pushSeqs(t, m, seqRange(1, 10)...)
+	pushSeqs(t, m, 13)
+	if want := []interface{}{uint64(90), uint64(100), uint64(130)}; !reflect.DeepEqual(m.Value(), want) {
+		t.Fatalf("Value() = %v; want %v", m.Value(), want)
+	}
+	fresh := fixedarr.NewMerkleArray(5, 2)
+	pushSeqs(t, fresh, 9, 10, 13)
+	if m.Root() != fresh.Root() {
+		t.Fatal("roots differ after a gap")
+	}
+
+	pushSeqs(t, m, 100)
+	if want := []interface{}{uint64(1000)}; !reflect.DeepEqual(m.Value(), want) {
+		t.Fatalf("Value() = %v; want %v", m.Value(), want)
+	}
+
+	if err := m.PushSeq(100, 0); err == nil {
+		t.Fatal("PushSeq of a repeated sequence number succeeded")
+	}
+	if err := fixedarr.NewMerkleArray(5, 2).PushSeq(0, 0); err == nil {
+		t.Fatal("PushSeq(0) succeeded")
+	}
+}
+
+func TestMerkleRandomGaps(t *testing.T) {
+	const maxSize, blockSize = 7, 3
+	rng := rand.New(rand.NewSource(1))
+	m := fixedarr.NewMerkleArray(maxSize, blockSize)
+	var seq uint64
+	var pushed []uint64
+	for i := 0; i < 2000; i++ {
+		seq += uint64(1 + rng.Intn(4))
+		if rng.Intn(20) == 0 {
+			seq += uint64(rng.Intn(3 * maxSize))
+		}
+		pushSeqs(t, m, seq)
+		pushed = append(pushed, seq)
+
+		// A fresh replica fed with the retained elements only.
+		var retained []uint64
+		for _, s := range pushed {
+			if s+maxSize > seq {
+				retained = append(retained, s)
+			}
+		}
+		fresh := fixedarr.NewMerkleArray(maxSize, blockSize)
+		pushSeqs(t, fresh, retained...)
+		if m.Root() != fresh.Root() {
+			t.Fatalf("push %d (seq %d): roots differ from a replica holding %v", i, seq, retained)
+		}
+	}
+}
+
+func TestMerkleOptions(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("NewMerkleArray with WithFold did not panic")
+		}
+	}()
+	fixedarr.NewMerkleArray(5, 2, fixedarr.WithFold(0, sum))
+}
@@ -0,0 +1,46 @@
+package fixedarr
+
+import "time"
+
+// periods is a fixed array holding an element per time period, oldest
+// first, such as the generations of an AgingBloom: every elapsed period
+// pushes a fresh element, dropping the oldest one.
+type periods struct {
+	*Array
+	fresh     func() interface{} // returns the element of a new period
+	period    time.Duration
+	clock     Clock
+	rotatedAt time.Time
+}
+
+// newPeriods returns an empty periods holding up to n elements; a period
+// too short to be measured lasts a nanosecond.
+func newPeriods(n int, period time.Duration, clock Clock, fresh func() interface{}) *periods {
+	if period <= 0 {
+		period = 1
+	}
+	return &periods{
+		Array:     New(n),
+		fresh:     fresh,
+		period:    period,
+		clock:     clock,
+		rotatedAt: clock.Now(),
+	}
+}
+
+// rotate pushes a fresh element for every period elapsed since the last
+// rotation; the lock of the owner MUST be held.
+func (p *periods) rotate() {
+	elapsed := p.clock.Now().Sub(p.rotatedAt)
+	if elapsed < p.period {
+		return
+	}
+	n := int64(elapsed / p.period)
+	p.rotatedAt = p.rotatedAt.Add(time.Duration(n) * p.period)
+	if max := int64(p.Max()); n > max {
+		n = max
+	}
+	for ; n > 0; n-- {
+		p.Push(p.fresh())
+	}
+}
@@ -0,0 +1,260 @@
+package fixedarr
+
+import (
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// PressureConfig configures a PressureController.
+type PressureConfig struct {
+	// SoftLimit is the heap size, in bytes, the controller tries to stay under.
+	SoftLimit uint64
+	// High and Low are fractions of SoftLimit: capacity is lowered one step
+	// at every check while the heap is above High, and raised back one step
+	// at every check while it is below Low. They default to 0.9 and 0.7.
+	High, Low float64
+	// Factor multiplies the capacity of the arrays at every step down;
+	// it defaults to 0.5.
+	Factor float64
+	// MinSize is the capacity arrays are never lowered below; it defaults to 1.
+	MinSize int
+	// Interval is the time between checks; it defaults to one second.
+	Interval time.Duration
+	// Clock measures Interval; it defaults to SystemClock.
+	Clock Clock
+	// HeapBytes returns the current heap size; it defaults to the
+	// /memory/classes/heap/objects:bytes runtime metric.
+	HeapBytes func() uint64
+	// OnAdjust, if set, is called whenever the capacity of an array changes;
+	// it is called without locks held, and can use the controller.
+	OnAdjust func(PressureEvent)
+}
+
+// PressureEvent describes a capacity change made by a PressureController.
+type PressureEvent struct {
+	Array     *Array
+	From, To  int
+	HeapBytes uint64
+	Level     int
+}
+
+// PressureController lowers the effective Max of the registered arrays
+// while the heap approaches a soft limit, and restores it once the pressure
+// drops. Registered arrays SHOULD NOT be resized with SetMax by others.
+type PressureController struct {
+	cfg PressureConfig
+
+	mu    *sync.Mutex
+	level int
+	base  map[*Array]int
+
+	stop chan struct{}
+	done chan struct{}
+	once *sync.Once
+}
+
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+func readHeapObjects() uint64 {
+	sample := []metrics.Sample{{Name: heapObjectsMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+// NewPressureController starts a controller checking the heap at every
+// cfg.Interval; cfg.SoftLimit MUST be positive.
+func NewPressureController(cfg PressureConfig) *PressureController {
+	if cfg.SoftLimit == 0 {
+		panic("fixedarr.NewPressureController: SoftLimit must be positive")
+	}
+	if cfg.High == 0 {
+		cfg.High = 0.9
+	}
+	if cfg.Low == 0 {
+		cfg.Low = 0.7
+	}
+	if cfg.Low > cfg.High {
+		panic("fixedarr.NewPressureController: Low must not exceed High")
+	}
+	if cfg.Factor <= 0 || cfg.Factor >= 1 {
+		cfg.Factor = 0.5
+	}
+	if cfg.MinSize < 1 {
+		cfg.MinSize = 1
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock
+	}
+	if cfg.HeapBytes == nil {
+		cfg.HeapBytes = readHeapObjects
+	}
+	p := &PressureController{
+		cfg:  cfg,
+		mu:   &sync.Mutex{},
+		base: make(map[*Array]int),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+		once: &sync.Once{},
+	}
+	ticker := cfg.Clock.NewTicker(cfg.Interval)
+	go p.loop(ticker)
+	return p
+}
+
+func (p *PressureController) loop(ticker Ticker) {
+	defer close(p.done)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			p.Check()
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// Register puts a under the control of p; its current Max is the capacity
+// restored when there is no pressure.
+func (p *PressureController) Register(a *Array) {
+	p.mu.Lock()
+	if _, ok := p.base[a]; ok {
+		p.mu.Unlock()
+		return
+	}
+	p.base[a] = a.Max()
+	events := p.apply(nil, a, 0)
+	p.mu.Unlock()
+
+	p.notify(events)
+}
+
+// Unregister restores the capacity of a and releases it from p.
+func (p *PressureController) Unregister(a *Array) {
+	p.mu.Lock()
+	base, ok := p.base[a]
+	if !ok {
+		p.mu.Unlock()
+		return
+	}
+	delete(p.base, a)
+	events := p.resize(nil, a, base, 0)
+	p.mu.Unlock()
+
+	p.notify(events)
+}
+
+// Level returns how many steps down the capacity currently is.
+func (p *PressureController) Level() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.level
+}
+
+// Check reads the heap size and adjusts the capacity of the arrays; it is
+// called at every interval, and can be called directly.
+func (p *PressureController) Check() {
+	heap := p.cfg.HeapBytes()
+
+	p.mu.Lock()
+	switch {
+	case float64(heap) >= p.cfg.High*float64(p.cfg.SoftLimit):
+		if !p.atFloor() {
+			p.level++
+		}
+	case float64(heap) <= p.cfg.Low*float64(p.cfg.SoftLimit):
+		if p.level > 0 {
+			p.level--
+		}
+	default:
+		p.mu.Unlock()
+		return
+	}
+	var events []PressureEvent
+	for a := range p.base {
+		events = p.apply(events, a, heap)
+	}
+	p.mu.Unlock()
+
+	p.notify(events)
+}
+
+// atFloor reports whether every array is already at MinSize; p.mu MUST be held.
+func (p *PressureController) atFloor() bool {
+	for _, base := range p.base {
+		if p.capacity(base) > p.cfg.MinSize {
+			return false
+		}
+	}
+	return true
+}
+
+// capacity returns the capacity at the current level of an array whose
+// unconstrained capacity is base; p.mu MUST be held.
+func (p *PressureController) capacity(base int) int {
+	n := float64(base)
+	for i := 0; i < p.level; i++ {
+		n *= p.cfg.Factor
+	}
+	if int(n) < p.cfg.MinSize {
+		if base < p.cfg.MinSize {
+			return base
+		}
+		return p.cfg.MinSize
+	}
+	return int(n)
+}
+
+// apply resizes a for the current level, and appends the resulting event,
+// if any, to events; p.mu MUST be held.
+func (p *PressureController) apply(events []PressureEvent, a *Array, heap uint64) []PressureEvent {
+	return p.resize(events, a, p.capacity(p.base[a]), heap)
+}
+
+// resize sets the Max of a to to, and appends the resulting event, if any,
+// to events; p.mu MUST be held.
+func (p *PressureController) resize(events []PressureEvent, a *Array, to int, heap uint64) []PressureEvent {
+	from := a.Max()
+	if from == to {
+		return events
+	}
+	a.SetMax(to)
+	return append(events, PressureEvent{Array: a, From: from, To: to, HeapBytes: heap, Level: p.level})
+}
+
+// notify passes events to OnAdjust; p.mu MUST NOT be held.
+func (p *PressureController) notify(events []PressureEvent) {
+	if p.cfg.OnAdjust == nil {
+		return
+	}
+	for _, e := range events {
+		p.cfg.OnAdjust(e)
+	}
+}
+
+// Close stops the controller and restores the capacity of every array.
+func (p *PressureController) Close() {
+	p.once.Do(func() {
+		close(p.stop)
+		<-p.done
+
+		p.mu.Lock()
+		var events []PressureEvent
+		for a, base := range p.base {
+			events = p.resize(events, a, base, 0)
+		}
+		p.base = make(map[*Array]int)
+		p.mu.Unlock()
+
+		p.notify(events)
+	})
+}
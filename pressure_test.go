@@ -0,0 +1,110 @@
+package fixedarr_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+func TestPressureController(t *testing.T) {
+	var heap atomic.Uint64
+	var p *fixedarr.PressureController
+	events := make(chan fixedarr.PressureEvent, 10)
+	p = fixedarr.NewPressureController(fixedarr.PressureConfig{
+		SoftLimit: 1000,
+		MinSize:   10,
+		Interval:  time.Hour,
+		Clock:     fixedarr.NewManualClock(time.Unix(0, 0)),
+		HeapBytes: heap.Load,
+		OnAdjust: func(e fixedarr.PressureEvent) {
+			if e.Level != p.Level() { // must not deadlock
+				t.Errorf("event level %d; controller at %d", e.Level, p.Level())
+			}
+			events <- e
+		},
+	})
+	defer p.Close()
+
+	a := fixedarr.New(100)
+	for i := 0; i < 100; i++ {
+		a.Push(i)
+	}
+	p.Register(a)
+
+	steps := []struct {
+		heap  uint64
+		level int
+		max   int
+	}{
+		{800, 0, 100}, // between Low and High: no change
+		{900, 1, 50},
+		{950, 2, 25},
+		{990, 3, 12},
+		{990, 4, 10}, // MinSize
+		{990, 4, 10}, // at the floor
+		{800, 4, 10},
+		{700, 3, 12},
+		{0, 2, 25},
+	}
+	for i, step := range steps {
+		heap.Store(step.heap)
+		p.Check()
+		if p.Level() != step.level || a.Max() != step.max {
+			t.Fatalf("step %d: Level() = %d, Max() = %d; want %d, %d", i, p.Level(), a.Max(), step.level, step.max)
+		}
+		if a.Len() > a.Max() {
+			t.Fatalf("step %d: Len() = %d over Max() = %d", i, a.Len(), a.Max())
+		}
+	}
+	if e := <-events; e.Array != a || e.From != 100 || e.To != 50 || e.HeapBytes != 900 || e.Level != 1 {
+		t.Fatalf("first event = %+v", e)
+	}
+
+	p.Unregister(a)
+	if a.Max() != 100 {
+		t.Fatalf("Max() = %d after Unregister; want 100", a.Max())
+	}
+	heap.Store(1000)
+	p.Check()
+	if a.Max() != 100 {
+		t.Fatalf("Max() = %d; an unregistered array is resized", a.Max())
+	}
+}
+
+func TestPressureControllerInterval(t *testing.T) {
+	clock := fixedarr.NewManualClock(time.Unix(0, 0))
+	var heap atomic.Uint64
+	heap.Store(2000)
+	events := make(chan fixedarr.PressureEvent, 10)
+	var p *fixedarr.PressureController
+	p = fixedarr.NewPressureController(fixedarr.PressureConfig{
+		SoftLimit: 1000,
+		Interval:  time.Second,
+		Clock:     clock,
+		HeapBytes: heap.Load,
+		OnAdjust: func(e fixedarr.PressureEvent) {
+			p.Level() // must not deadlock
+			events <- e
+		},
+	})
+	a := fixedarr.New(8)
+	p.Register(a)
+
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Second)
+	if e := <-events; e.From != 8 || e.To != 4 {
+		t.Fatalf("event = %+v; want 8 to 4", e)
+	}
+
+	p.Close()
+	if e := <-events; e.From != 4 || e.To != 8 {
+		t.Fatalf("event = %+v on Close; want 4 to 8", e)
+	}
+	if a.Max() != 8 {
+		t.Fatalf("Max() = %d after Close; want 8", a.Max())
+	}
+}
@@ -0,0 +1,207 @@
+package fixedarr
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// QuantileConfig configures a QuantileWindow.
+type QuantileConfig struct {
+	// Window is the time span quantiles are computed over.
+	Window time.Duration
+	// Intervals is the number of sketches the window is split into; it
+	// defaults to 60. Quantiles cover at least
+	// Window*(Intervals-1)/Intervals, and at most Window.
+	Intervals int
+	// RelativeAccuracy is the maximum relative error of the quantiles; it
+	// defaults to 0.01.
+	RelativeAccuracy float64
+	// MaxBins bounds the number of bins of every sketch, for each sign;
+	// past it, the bins of the values closest to zero are collapsed, losing
+	// accuracy on those values only. It defaults to 2048.
+	MaxBins int
+	// Clock measures Window; it defaults to SystemClock.
+	Clock Clock
+}
+
+// QuantileWindow estimates quantiles of the values added within a sliding
+// time window, with a bounded relative error (it uses DDSketch) and memory
+// independent of the number of values. It is a fixed array of sketches,
+// one per interval, merged at query time.
+type QuantileWindow struct {
+	mu       *sync.Mutex
+	sketches *periods // of *ddSketch
+	gamma    float64
+	logGamma float64
+	maxBins  int
+}
+
+// ddSketch counts values in logarithmically sized bins: value v > 0 goes to
+// bin ceil(log_gamma(v)), and -v to the same bin of negative.
+type ddSketch struct {
+	positive map[int]uint64
+	negative map[int]uint64
+	zero     uint64
+	count    uint64
+}
+
+func newDDSketch() *ddSketch {
+	return &ddSketch{
+		positive: make(map[int]uint64),
+		negative: make(map[int]uint64),
+	}
+}
+
+// NewQuantileWindow returns a new QuantileWindow; cfg.Window MUST be positive.
+func NewQuantileWindow(cfg QuantileConfig) *QuantileWindow {
+	if cfg.Window <= 0 {
+		panic("fixedarr.NewQuantileWindow: Window must be positive")
+	}
+	if cfg.Intervals < 1 {
+		cfg.Intervals = 60
+	}
+	if cfg.RelativeAccuracy <= 0 || cfg.RelativeAccuracy >= 1 {
+		cfg.RelativeAccuracy = 0.01
+	}
+	if cfg.MaxBins < 1 {
+		cfg.MaxBins = 2048
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock
+	}
+	gamma := (1 + cfg.RelativeAccuracy) / (1 - cfg.RelativeAccuracy)
+	period := cfg.Window / time.Duration(cfg.Intervals)
+	w := &QuantileWindow{
+		mu:       &sync.Mutex{},
+		sketches: newPeriods(cfg.Intervals, period, cfg.Clock, func() interface{} { return newDDSketch() }),
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		maxBins:  cfg.MaxBins,
+	}
+	w.sketches.Push(newDDSketch())
+	return w
+}
+
+// Add records v; NaN and infinite values are ignored.
+func (w *QuantileWindow) Add(v float64) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.sketches.rotate()
+	s := w.sketches.array[len(w.sketches.array)-1].(*ddSketch)
+	s.count++
+	switch {
+	case v > 0:
+		w.addBin(s.positive, w.index(v))
+	case v < 0:
+		w.addBin(s.negative, w.index(-v))
+	default:
+		s.zero++
+	}
+}
+
+func (w *QuantileWindow) index(v float64) int {
+	return int(math.Ceil(math.Log(v) / w.logGamma))
+}
+
+// value returns the estimate of the values of bin i, within the relative
+// accuracy of all of them.
+func (w *QuantileWindow) value(i int) float64 {
+	return 2 * math.Pow(w.gamma, float64(i)) / (w.gamma + 1)
+}
+
+// addBin counts a value in bin i of bins, collapsing the lowest bins if
+// there are too many.
+func (w *QuantileWindow) addBin(bins map[int]uint64, i int) {
+	bins[i]++
+	if len(bins) <= w.maxBins {
+		return
+	}
+	lowest, next := math.MaxInt, math.MaxInt
+	for j := range bins {
+		if j < lowest {
+			lowest, next = j, lowest
+		} else if j < next {
+			next = j
+		}
+	}
+	bins[next] += bins[lowest]
+	delete(bins, lowest)
+}
+
+// Count returns the number of values recorded within the window.
+func (w *QuantileWindow) Count() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.sketches.rotate()
+	var n uint64
+	for _, el := range w.sketches.array {
+		n += el.(*ddSketch).count
+	}
+	return n
+}
+
+// Quantile returns an estimate of the q-quantile (0 <= q <= 1) of the values
+// recorded within the window, and false if there are none.
+func (w *QuantileWindow) Quantile(q float64) (float64, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.sketches.rotate()
+	merged := newDDSketch()
+	for _, el := range w.sketches.array {
+		s := el.(*ddSketch)
+		for i, n := range s.positive {
+			merged.positive[i] += n
+		}
+		for i, n := range s.negative {
+			merged.negative[i] += n
+		}
+		merged.zero += s.zero
+		merged.count += s.count
+	}
+	if merged.count == 0 {
+		return 0, false
+	}
+	if q < 0 {
+		q = 0
+	} else if q > 1 {
+		q = 1
+	}
+	rank := uint64(q * float64(merged.count-1))
+
+	// Walk the values in ascending order: negative bins from the largest
+	// magnitude, then zero, then positive bins.
+	var seen uint64
+	negative := sortedBins(merged.negative)
+	for k := len(negative) - 1; k >= 0; k-- {
+		if seen += merged.negative[negative[k]]; seen > rank {
+			return -w.value(negative[k]), true
+		}
+	}
+	if seen += merged.zero; seen > rank {
+		return 0, true
+	}
+	positive := sortedBins(merged.positive)
+	for _, i := range positive {
+		if seen += merged.positive[i]; seen > rank {
+			return w.value(i), true
+		}
+	}
+	return w.value(positive[len(positive)-1]), true
+}
+
+func sortedBins(bins map[int]uint64) []int {
+	keys := make([]int, 0, len(bins))
+	for i := range bins {
+		keys = append(keys, i)
+	}
+	sort.Ints(keys)
+	return keys
+}
@@ -0,0 +1,159 @@
+package fixedarr_test
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+// rounding absorbs the floating-point error of values on bin boundaries,
+// whose estimates are exactly at the relative accuracy.
+const rounding = 1e-12
+
+func newQuantileWindow(cfg fixedarr.QuantileConfig) (*fixedarr.QuantileWindow, *fixedarr.ManualClock) {
+	clock := fixedarr.NewManualClock(time.Unix(0, 0))
+	if cfg.Window == 0 {
+		cfg.Window = time.Minute
+	}
+	cfg.Clock = clock
+	return fixedarr.NewQuantileWindow(cfg), clock
+}
+
+// checkQuantiles checks the quantiles of w against those of the sorted
+// values, for every q in qs.
+func checkQuantiles(t *testing.T, w *fixedarr.QuantileWindow, sorted []float64, accuracy float64, qs []float64) {
+	t.Helper()
+	for _, q := range qs {
+		got, ok := w.Quantile(q)
+		exact := sorted[int(q*float64(len(sorted)-1))]
+		if !ok {
+			t.Fatalf("Quantile(%v) = _, false", q)
+		}
+		if err := math.Abs(got - exact); err > (accuracy+rounding)*math.Abs(exact) {
+			t.Fatalf("Quantile(%v) = %v; exact %v, error %.6f", q, got, exact, err/math.Abs(exact))
+		}
+	}
+}
+
+func steps(from, to, step float64) []float64 {
+	var qs []float64
+	for q := from; q <= to; q += step {
+		qs = append(qs, q)
+	}
+	return append(qs, to)
+}
+
+func TestQuantileAccuracy(t *testing.T) {
+	for _, accuracy := range []float64{0.01, 0.05} {
+		w, _ := newQuantileWindow(fixedarr.QuantileConfig{RelativeAccuracy: accuracy})
+		values := make([]float64, 10000)
+		for i := range values {
+			values[i] = float64(i + 1)
+		}
+		rand.New(rand.NewSource(1)).Shuffle(len(values), func(i, j int) {
+			values[i], values[j] = values[j], values[i]
+		})
+		for _, v := range values {
+			w.Add(v)
+		}
+		sort.Float64s(values)
+		checkQuantiles(t, w, values, accuracy, steps(0, 1, 0.001))
+
+		// 9900, the exact p99, lies just below a bin boundary: its estimate
+		// is about 1% lower, within the bound.
+		if accuracy == 0.01 {
+			got, _ := w.Quantile(0.99)
+			if err := (9900 - got) / 9900; err < 0.0099 || err > 0.01+rounding {
+				t.Fatalf("Quantile(0.99) = %v; want 1%% below 9900", got)
+			}
+		}
+	}
+}
+
+func TestQuantileBinBoundaries(t *testing.T) {
+	// Values on, and next to, the bin boundaries (the powers of gamma)
+	// have the largest error, which still stays within the accuracy.
+	const accuracy = 0.01
+	gamma := (1 + accuracy) / (1 - accuracy)
+	for i := -300; i <= 300; i++ {
+		b := math.Pow(gamma, float64(i))
+		for _, v := range []float64{b, math.Nextafter(b, 0), math.Nextafter(b, math.Inf(1))} {
+			w, _ := newQuantileWindow(fixedarr.QuantileConfig{})
+			w.Add(v)
+			got, _ := w.Quantile(0.5)
+			if err := math.Abs(got-v) / v; err > accuracy+rounding {
+				t.Fatalf("value %v estimated as %v, error %v", v, got, err)
+			}
+		}
+	}
+}
+
+func TestQuantileSigns(t *testing.T) {
+	w, _ := newQuantileWindow(fixedarr.QuantileConfig{})
+	var values []float64
+	for v := -1000; v <= 1000; v++ {
+		values = append(values, float64(v))
+		w.Add(float64(v))
+	}
+	w.Add(math.NaN())
+	w.Add(math.Inf(1))
+	if n := w.Count(); n != uint64(len(values)) {
+		t.Fatalf("Count() = %d; want %d, ignoring NaN and Inf", n, len(values))
+	}
+	checkQuantiles(t, w, values, 0.01, steps(0, 1, 0.01))
+	if got, _ := w.Quantile(0.5); got != 0 {
+		t.Fatalf("Quantile(0.5) = %v; want 0", got)
+	}
+	if got, _ := w.Quantile(-1); got >= -990 {
+		t.Fatalf("Quantile(-1) = %v; want the minimum", got)
+	}
+}
+
+func TestQuantileMaxBins(t *testing.T) {
+	// 16 bins span a factor of gamma^16, about 1.38: the values above
+	// 10000/1.38 keep their accuracy, and the lower ones are collapsed
+	// into the lowest remaining bin.
+	w, _ := newQuantileWindow(fixedarr.QuantileConfig{MaxBins: 16})
+	values := make([]float64, 10000)
+	for i := range values {
+		values[i] = float64(i + 1)
+		w.Add(values[i])
+	}
+	checkQuantiles(t, w, values, 0.01, steps(0.75, 1, 0.001))
+	if got, _ := w.Quantile(0); got < 7000 {
+		t.Fatalf("Quantile(0) = %v; want the lowest remaining bin", got)
+	}
+}
+
+func TestQuantileWindowExpiry(t *testing.T) {
+	w, clock := newQuantileWindow(fixedarr.QuantileConfig{Window: 10 * time.Second, Intervals: 10})
+	if _, ok := w.Quantile(0.5); ok {
+		t.Fatal("Quantile() = _, true for an empty window")
+	}
+	w.Add(1)
+	clock.Advance(5 * time.Second)
+	w.Add(2)
+
+	// The first value is dropped with its interval, 10s after it began.
+	clock.Advance(5*time.Second - time.Nanosecond)
+	if n := w.Count(); n != 2 {
+		t.Fatalf("Count() = %d; want 2", n)
+	}
+	clock.Advance(time.Nanosecond)
+	if n := w.Count(); n != 1 {
+		t.Fatalf("Count() = %d after 10s; want 1", n)
+	}
+	if got, _ := w.Quantile(0); math.Abs(got-2) > 0.02 {
+		t.Fatalf("Quantile(0) = %v; want about 2", got)
+	}
+
+	// An idle period longer than the window empties it.
+	clock.Advance(time.Hour)
+	if _, ok := w.Quantile(0.5); ok || w.Count() != 0 {
+		t.Fatal("values left after an idle hour")
+	}
+}
@@ -0,0 +1,116 @@
+package fixedarr
+
+import "sync"
+
+// ReplayWindow is an anti-replay window, as used by IPsec: it accepts a
+// sequence number if it is newer than the highest one seen so far, or
+// within the last size sequence numbers and not seen before. It keeps a
+// ring of bits, one per sequence number in the window, plus one spare
+// word so that advancing only has to clear whole words (see RFC 6479);
+// every check is O(1).
+type ReplayWindow struct {
+	mu      *sync.Mutex
+	size    uint64
+	words   []uint64
+	highest uint64
+	started bool
+}
+
+// NewReplayWindow returns a new ReplayWindow of size sequence numbers;
+// size MUST be a positive number.
+func NewReplayWindow(size int) *ReplayWindow {
+	if size < 1 {
+		panic("fixedarr.NewReplayWindow: size must be positive")
+	}
+	return &ReplayWindow{
+		mu:    &sync.Mutex{},
+		size:  uint64(size),
+		words: make([]uint64, (size+63)/64+1),
+	}
+}
+
+// Check reports whether seq would be accepted, without recording it.
+func (w *ReplayWindow) Check(seq uint64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.check(seq)
+}
+
+// Accept reports whether seq is accepted, and if so records it as seen.
+func (w *ReplayWindow) Accept(seq uint64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.check(seq) {
+		return false
+	}
+	if !w.started || seq > w.highest {
+		w.advance(seq)
+	}
+	word, bit := w.position(seq)
+	w.words[word] |= bit
+	return true
+}
+
+// Highest returns the highest sequence number accepted, and false if none
+// has been accepted yet.
+func (w *ReplayWindow) Highest() (uint64, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.highest, w.started
+}
+
+// Size returns the size of the window
+func (w *ReplayWindow) Size() int {
+	return int(w.size)
+}
+
+// Reset forgets every sequence number seen.
+func (w *ReplayWindow) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i := range w.words {
+		w.words[i] = 0
+	}
+	w.highest = 0
+	w.started = false
+}
+
+// check implements Check; w.mu MUST be held.
+func (w *ReplayWindow) check(seq uint64) bool {
+	switch {
+	case !w.started || seq > w.highest:
+		return true
+	case w.highest-seq >= w.size:
+		return false
+	}
+	word, bit := w.position(seq)
+	return w.words[word]&bit == 0
+}
+
+// advance makes seq the highest sequence number, clearing the words that
+// enter the window; w.mu MUST be held.
+func (w *ReplayWindow) advance(seq uint64) {
+	if w.started {
+		n := uint64(len(w.words))
+		oldWord, newWord := w.highest/64, seq/64
+		diff := newWord - oldWord
+		if diff > n {
+			diff = n
+		}
+		for i := uint64(1); i <= diff; i++ {
+			w.words[(oldWord+i)%n] = 0
+		}
+	}
+	w.highest = seq
+	w.started = true
+}
+
+// position returns the index of the word holding the bit of seq, and the
+// bit itself.
+func (w *ReplayWindow) position(seq uint64) (int, uint64) {
+	return int((seq / 64) % uint64(len(w.words))), 1 << (seq % 64)
+}
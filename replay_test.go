@@ -0,0 +1,127 @@
+package fixedarr_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+// replayModel is a trivially correct anti-replay window.
+type replayModel struct {
+	size    uint64
+	seen    map[uint64]bool
+	highest uint64
+	started bool
+}
+
+func (m *replayModel) check(seq uint64) bool {
+	switch {
+	case !m.started || seq > m.highest:
+		return true
+	case m.highest-seq >= m.size:
+		return false
+	}
+	return !m.seen[seq]
+}
+
+func (m *replayModel) accept(seq uint64) bool {
+	if !m.check(seq) {
+		return false
+	}
+	if !m.started || seq > m.highest {
+		m.highest, m.started = seq, true
+	}
+	m.seen[seq] = true
+	return true
+}
+
+var replaySizes = []int{1, 2, 63, 64, 65, 127, 128, 129, 200}
+
+func TestReplayWindowModel(t *testing.T) {
+	starts := []uint64{0, 1, 63, 64, 1000, math.MaxUint64 - 5000}
+	for _, size := range replaySizes {
+		for _, start := range starts {
+			r := rand.New(rand.NewSource(int64(size) ^ int64(start)))
+			w := fixedarr.NewReplayWindow(size)
+			m := &replayModel{size: uint64(size), seen: map[uint64]bool{}}
+			seq := start
+			for i := 0; i < 5000; i++ {
+				// Mostly small moves around the highest, sometimes jumps
+				// over several ring lengths.
+				low := start
+				if m.highest-start > uint64(size) {
+					low = m.highest - uint64(size)
+				}
+				switch p := r.Intn(100); {
+				case p < 70:
+					seq = low + uint64(r.Intn(2*size+2))
+				case p < 90:
+					seq = m.highest + uint64(r.Intn(130))
+				default:
+					seq = m.highest + uint64(r.Intn(1000))
+				}
+				if seq < start { // wrapped around math.MaxUint64
+					seq = math.MaxUint64
+				}
+				if got, want := w.Check(seq), m.check(seq); got != want {
+					t.Fatalf("size %d, start %d, op %d: Check(%d) = %v; want %v", size, start, i, seq, got, want)
+				}
+				if got, want := w.Accept(seq), m.accept(seq); got != want {
+					t.Fatalf("size %d, start %d, op %d: Accept(%d) = %v; want %v", size, start, i, seq, got, want)
+				}
+				if h, _ := w.Highest(); h != m.highest {
+					t.Fatalf("size %d, start %d, op %d: Highest() = %d; want %d", size, start, i, h, m.highest)
+				}
+			}
+		}
+	}
+}
+
+// TestReplayWindowAdvance checks every advance distance up to two ring
+// lengths, from the edges of a word, against every sequence number around
+// the window.
+func TestReplayWindowAdvance(t *testing.T) {
+	for _, size := range replaySizes {
+		ring := uint64((size+63)/64+1) * 64
+		for _, from := range []uint64{0, 1, 62, 63} {
+			for step := uint64(0); step <= 2*ring+1; step++ {
+				w := fixedarr.NewReplayWindow(size)
+				m := &replayModel{size: uint64(size), seen: map[uint64]bool{}}
+				base := 1000 + from
+				// Fill the window below base, skipping every third number.
+				for seq := base - uint64(size); seq <= base; seq++ {
+					if seq%3 != 0 {
+						w.Accept(seq)
+						m.accept(seq)
+					}
+				}
+				w.Accept(base + step)
+				m.accept(base + step)
+				for seq := base - uint64(size); seq <= base+step+1; seq++ {
+					if got, want := w.Check(seq), m.check(seq); got != want {
+						t.Fatalf("size %d, base %d, step %d: Check(%d) = %v; want %v", size, base, step, seq, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestReplayWindowReset(t *testing.T) {
+	w := fixedarr.NewReplayWindow(64)
+	if _, ok := w.Highest(); ok {
+		t.Fatal("Highest() reported a sequence number before any Accept")
+	}
+	if !w.Accept(10) || w.Accept(10) || !w.Accept(9) {
+		t.Fatal("duplicate detection is wrong")
+	}
+	w.Reset()
+	if !w.Accept(10) {
+		t.Fatal("Accept(10) = false after Reset")
+	}
+	if h, ok := w.Highest(); !ok || h != 10 {
+		t.Fatalf("Highest() = %d, %v; want 10, true", h, ok)
+	}
+}
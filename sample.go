@@ -0,0 +1,103 @@
+package fixedarr
+
+import (
+	"math/rand"
+	"time"
+)
+
+// WithSampling makes Push admit elements at random when they arrive faster
+// than maxPerSecond, keeping each with a probability adjusted to the input
+// rate so that about maxPerSecond are admitted every second; see Sampling.
+// The input rate is measured on the Clock of the array.
+func WithSampling(maxPerSecond float64) Option {
+	if maxPerSecond <= 0 {
+		panic("fixedarr.WithSampling: maxPerSecond must be positive")
+	}
+	return func(a *Array) {
+		a.sampler = &sampler{target: maxPerSecond, keep: 1}
+	}
+}
+
+// SamplingStats describes the sampling of an array. KeepProbability only
+// applies to the next push, not to the elements already admitted: to
+// estimate input totals, scale those of the admitted elements by
+// (Admitted+Dropped)/Admitted.
+type SamplingStats struct {
+	// InputRate is the estimated number of pushes per second.
+	InputRate float64
+	// KeepProbability is the probability a push is currently admitted with.
+	KeepProbability float64
+	Admitted        uint64
+	Dropped         uint64
+}
+
+// samplingPeriod is the period the input rate is measured over; it is
+// smoothed across periods with an exponentially weighted moving average.
+const samplingPeriod = time.Second
+
+type sampler struct {
+	target   float64
+	rate     float64 // smoothed input rate, per second
+	keep     float64
+	start    time.Time // of the current period
+	count    uint64    // pushes in the current period
+	admitted uint64
+	dropped  uint64
+}
+
+// admit counts a push at now, and reports whether it is admitted; the lock
+// of the array MUST be held.
+func (s *sampler) admit(now time.Time) bool {
+	if s.start.IsZero() {
+		s.start = now
+	}
+	elapsed := now.Sub(s.start)
+	if elapsed >= samplingPeriod {
+		current := float64(s.count) / elapsed.Seconds()
+		if s.rate == 0 {
+			s.rate = current
+		} else {
+			s.rate = 0.5*s.rate + 0.5*current
+		}
+		s.start, s.count, elapsed = now, 0, 0
+	}
+	s.count++
+
+	// React to spikes within the current period, without waiting for it
+	// to end.
+	rate := s.rate
+	if elapsed >= samplingPeriod/10 {
+		if current := float64(s.count) / elapsed.Seconds(); current > rate {
+			rate = current
+		}
+	}
+	s.keep = 1
+	if rate > s.target {
+		s.keep = s.target / rate
+	}
+
+	if s.keep < 1 && rand.Float64() >= s.keep {
+		s.dropped++
+		return false
+	}
+	s.admitted++
+	return true
+}
+
+// Sampling returns the sampling statistics of the array, which are all zero
+// if the array was created without WithSampling.
+func (a *Array) Sampling() SamplingStats {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	s := a.sampler
+	if s == nil {
+		return SamplingStats{}
+	}
+	return SamplingStats{
+		InputRate:       s.rate,
+		KeepProbability: s.keep,
+		Admitted:        s.admitted,
+		Dropped:         s.dropped,
+	}
+}
@@ -0,0 +1,59 @@
+package fixedarr_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+// pushAt pushes n elements to a, one every interval of clock.
+func pushAt(a *fixedarr.Array, clock *fixedarr.ManualClock, n int, interval time.Duration) {
+	for i := 0; i < n; i++ {
+		a.Push(i)
+		clock.Advance(interval)
+	}
+}
+
+func TestSamplingConverges(t *testing.T) {
+	clock := fixedarr.NewManualClock(time.Unix(0, 0))
+	a := fixedarr.New(10, fixedarr.WithSampling(100), fixedarr.WithClock(clock))
+
+	// 1000 pushes per second, for 10s to settle and 10s measured.
+	pushAt(a, clock, 10000, time.Millisecond)
+	before := a.Sampling()
+	pushAt(a, clock, 10000, time.Millisecond)
+	s := a.Sampling()
+
+	// The number admitted is binomial, with a deviation of about 30.
+	if admitted := s.Admitted - before.Admitted; admitted < 850 || admitted > 1150 {
+		t.Fatalf("admitted %d in 10s; want about 1000", admitted)
+	}
+	if s.Admitted+s.Dropped != 20000 {
+		t.Fatalf("Admitted + Dropped = %d; want the 20000 pushes", s.Admitted+s.Dropped)
+	}
+	if math.Abs(s.InputRate-1000) > 10 {
+		t.Fatalf("InputRate = %v; want 1000", s.InputRate)
+	}
+	if math.Abs(s.KeepProbability-0.1) > 0.001 {
+		t.Fatalf("KeepProbability = %v; want 0.1", s.KeepProbability)
+	}
+}
+
+func TestSamplingBelowTarget(t *testing.T) {
+	clock := fixedarr.NewManualClock(time.Unix(0, 0))
+	a := fixedarr.New(10, fixedarr.WithSampling(100), fixedarr.WithClock(clock))
+	pushAt(a, clock, 500, 20*time.Millisecond)
+
+	s := a.Sampling()
+	if s.Admitted != 500 || s.Dropped != 0 || s.KeepProbability != 1 {
+		t.Fatalf("Sampling() = %+v; want every push admitted", s)
+	}
+	if math.Abs(s.InputRate-50) > 1 {
+		t.Fatalf("InputRate = %v; want 50", s.InputRate)
+	}
+	if (fixedarr.New(1).Sampling() != fixedarr.SamplingStats{}) {
+		t.Fatal("Sampling() is not zero without WithSampling")
+	}
+}
@@ -0,0 +1,332 @@
+package fixedarr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"time"
+)
+
+// ErrCorrupt is returned (wrapped) when a snapshot is truncated or corrupt.
+var ErrCorrupt = errors.New("fixedarr: corrupt snapshot")
+
+// Snapshot layout:
+//
+//	magic "FXAR" | version | flags | maxSize (uvarint) | count (uvarint) |
+//	next (uvarint) | [length (uvarint) | extra] |
+//	count * (seq (uvarint) | [coalesced] | length (uvarint) | element) |
+//	CRC-32 (IEEE) of all the above
+//
+// extra is only present with flagExtra; it holds the state of the types
+// built on Array, such as the anchor of a Chain.
+//
+// coalesced is only present with flagCoalesced, set by arrays created with
+// WithCoalesce: it is the Count (uvarint) of a Coalesced element, followed
+// by its FirstSeen and LastSeen as seconds (varint) and nanoseconds
+// (uvarint), and element is the encoding of its Value. A zero Count marks
+// an element that is not a Coalesced, such as one restored from a snapshot
+// of an array without WithCoalesce.
+//
+// Version 1 snapshots have no sequence numbers (next and seq are missing);
+// they are still readable, and their elements get new sequence numbers.
+const (
+	snapshotMagic   = "FXAR"
+	snapshotVersion = 2
+
+	flagAtCapacity = 1 << 0
+	flagExtra      = 1 << 1
+	flagCoalesced  = 1 << 2
+)
+
+// snapshot is the state of an array saved by MarshalBinary.
+type snapshot struct {
+	els        []interface{}
+	seqs       []uint64 // nil for version 1 snapshots
+	next       uint64
+	maxSize    int
+	atCapacity bool
+	coalesced  bool
+	extra      []byte
+}
+
+// capture returns the current state of the array; a.mu MUST be held.
+func (a *Array) capture() snapshot {
+	return snapshot{
+		els:        append([]interface{}{}, a.array...),
+		seqs:       append([]uint64{}, a.seqs...),
+		next:       a.next,
+		maxSize:    a.maxSize,
+		atCapacity: a.atCapacity,
+		coalesced:  a.coalesce != nil,
+	}
+}
+
+// MarshalBinary encodes the limit size, the elements (with the Codec of the
+// array) and their sequence numbers, and the at-capacity state of the
+// array, sealed in a segment if transforms are configured; it implements
+// encoding.BinaryMarshaler.
+func (a *Array) MarshalBinary() ([]byte, error) {
+	a.mu.RLock()
+	s := a.capture()
+	a.mu.RUnlock()
+
+	return a.encode(s)
+}
+
+// encode encodes s with the Codec of the array, and seals it with its
+// transforms, if any.
+func (a *Array) encode(s snapshot) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(snapshotMagic)
+	buf.WriteByte(snapshotVersion)
+	var flags byte
+	if s.atCapacity {
+		flags |= flagAtCapacity
+	}
+	if s.extra != nil {
+		flags |= flagExtra
+	}
+	if s.coalesced {
+		flags |= flagCoalesced
+	}
+	buf.WriteByte(flags)
+	buf.Write(binary.AppendUvarint(nil, uint64(s.maxSize)))
+	buf.Write(binary.AppendUvarint(nil, uint64(len(s.els))))
+	buf.Write(binary.AppendUvarint(nil, s.next))
+	if s.extra != nil {
+		buf.Write(binary.AppendUvarint(nil, uint64(len(s.extra))))
+		buf.Write(s.extra)
+	}
+	for i, el := range s.els {
+		buf.Write(binary.AppendUvarint(nil, s.seqs[i]))
+		if s.coalesced {
+			if c, ok := el.(Coalesced); ok {
+				buf.Write(binary.AppendUvarint(nil, uint64(c.Count)))
+				buf.Write(appendTime(nil, c.FirstSeen))
+				buf.Write(appendTime(nil, c.LastSeen))
+				el = c.Value
+			} else {
+				buf.WriteByte(0)
+			}
+		}
+		data, err := a.codec.Encode(el)
+		if err != nil {
+			return nil, fmt.Errorf("fixedarr: encoding element %d: %w", i, err)
+		}
+		buf.Write(binary.AppendUvarint(nil, uint64(len(data))))
+		buf.Write(data)
+	}
+	buf.Write(binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(buf.Bytes())))
+	if len(a.transforms) > 0 {
+		return sealSegment(buf.Bytes(), a.transforms)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary replaces the limit size, the elements and their sequence
+// numbers, and the at-capacity state of the array with the ones encoded by
+// MarshalBinary; it implements encoding.BinaryUnmarshaler. Errors caused by
+// a damaged input wrap ErrCorrupt, and those caused by a tampered input or
+// a wrong key wrap ErrAuth. Handles to the elements the array held are
+// never resolved to restored elements: the saved sequence numbers are only
+// kept by a new array.
+func (a *Array) UnmarshalBinary(data []byte) error {
+	s, err := a.decode(data)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	old := a.restore(s)
+	budget := a.budget
+	a.mu.Unlock()
+
+	if budget != nil {
+		budget.adjust(budget.weighAll(s.els) - budget.weighAll(old))
+	}
+	return nil
+}
+
+// decode opens and decodes a snapshot encoded by encode.
+func (a *Array) decode(data []byte) (snapshot, error) {
+	var s snapshot
+	switch {
+	case isSegment(data):
+		var err error
+		if data, err = openSegment(data, a.transforms); err != nil {
+			return s, err
+		}
+	case len(a.transforms) > 0:
+		return s, errors.New("fixedarr: snapshot is not sealed, but transforms are configured")
+	}
+	if len(data) < len(snapshotMagic)+2+4 {
+		return s, fmt.Errorf("%w: truncated (%d bytes)", ErrCorrupt, len(data))
+	}
+	if string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return s, fmt.Errorf("%w: bad magic", ErrCorrupt)
+	}
+	body, sum := data[:len(data)-4], binary.BigEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return s, fmt.Errorf("%w: checksum mismatch (truncated or damaged)", ErrCorrupt)
+	}
+	body = body[len(snapshotMagic):]
+	version := body[0]
+	if version < 1 || version > snapshotVersion {
+		return s, fmt.Errorf("fixedarr: unsupported snapshot version %d", version)
+	}
+	flags := body[1]
+	body = body[2:]
+	s.atCapacity = flags&flagAtCapacity != 0
+
+	maxSize, body, err := readUvarint(body, "maxSize")
+	if err != nil {
+		return s, err
+	}
+	count, body, err := readUvarint(body, "count")
+	if err != nil {
+		return s, err
+	}
+	if maxSize > 0 && count > maxSize {
+		return s, fmt.Errorf("%w: %d elements exceed maxSize %d", ErrCorrupt, count, maxSize)
+	}
+	if count > uint64(len(body)) {
+		return s, fmt.Errorf("%w: %d elements overflow snapshot", ErrCorrupt, count)
+	}
+	if maxSize > math.MaxInt {
+		return s, fmt.Errorf("%w: maxSize %d overflows int", ErrCorrupt, maxSize)
+	}
+	s.maxSize = int(maxSize)
+	if version >= 2 {
+		if s.next, body, err = readUvarint(body, "next"); err != nil {
+			return s, err
+		}
+		s.seqs = make([]uint64, 0, count)
+	}
+	if version >= 2 && flags&flagExtra != 0 {
+		var n uint64
+		if n, body, err = readUvarint(body, "extra length"); err != nil {
+			return s, err
+		}
+		if n > uint64(len(body)) {
+			return s, fmt.Errorf("%w: extra overflows snapshot", ErrCorrupt)
+		}
+		s.extra, body = body[:n:n], body[n:]
+	}
+	s.els = make([]interface{}, 0, count)
+	for i := uint64(0); i < count; i++ {
+		if version >= 2 {
+			var seq uint64
+			seq, body, err = readUvarint(body, "sequence number")
+			if err != nil {
+				return s, err
+			}
+			if seq > s.next || len(s.seqs) > 0 && seq <= s.seqs[len(s.seqs)-1] {
+				return s, fmt.Errorf("%w: bad sequence number of element %d", ErrCorrupt, i)
+			}
+			s.seqs = append(s.seqs, seq)
+		}
+		var c Coalesced
+		if version >= 2 && flags&flagCoalesced != 0 {
+			var count uint64
+			if count, body, err = readUvarint(body, "coalesced count"); err != nil {
+				return s, err
+			}
+			if count > math.MaxInt {
+				return s, fmt.Errorf("%w: bad coalesced count of element %d", ErrCorrupt, i)
+			}
+			c.Count = int(count)
+			if count > 0 {
+				if c.FirstSeen, body, err = readTime(body, "first seen"); err != nil {
+					return s, err
+				}
+				if c.LastSeen, body, err = readTime(body, "last seen"); err != nil {
+					return s, err
+				}
+			}
+		}
+		var n uint64
+		n, body, err = readUvarint(body, "element length")
+		if err != nil {
+			return s, err
+		}
+		if n > uint64(len(body)) {
+			return s, fmt.Errorf("%w: element %d overflows snapshot", ErrCorrupt, i)
+		}
+		el, err := a.codec.Decode(body[:n])
+		if err != nil {
+			return s, fmt.Errorf("%w: decoding element %d: %v", ErrCorrupt, i, err)
+		}
+		if c.Count > 0 {
+			c.Value = el
+			el = c
+		}
+		s.els = append(s.els, el)
+		body = body[n:]
+	}
+	if len(body) != 0 {
+		return s, fmt.Errorf("%w: %d trailing bytes", ErrCorrupt, len(body))
+	}
+	return s, nil
+}
+
+// restore replaces the state of the array with s, and returns the elements
+// it held; a.mu MUST be held.
+func (a *Array) restore(s snapshot) []interface{} {
+	old := a.array
+	a.array = s.els
+	// The saved sequence numbers are only kept by an array that never had
+	// elements: otherwise, a Handle to an element it held could resolve to
+	// a restored one.
+	if s.seqs != nil && a.next == 0 {
+		a.seqs, a.next = s.seqs, s.next
+	} else {
+		a.seqs = make([]uint64, len(s.els))
+		for i := range a.seqs {
+			a.next++
+			a.seqs[i] = a.next
+		}
+	}
+	a.maxSize = s.maxSize
+	a.atCapacity = s.atCapacity
+	for _, v := range a.views {
+		v.arr.Reset()
+		for _, el := range s.els {
+			v.offer(a.raw(el))
+		}
+	}
+	return old
+}
+
+// appendTime appends t as seconds (varint) and nanoseconds (uvarint) since
+// the Unix epoch.
+func appendTime(buf []byte, t time.Time) []byte {
+	buf = binary.AppendVarint(buf, t.Unix())
+	return binary.AppendUvarint(buf, uint64(t.Nanosecond()))
+}
+
+// readTime reads a time appended by appendTime.
+func readTime(buf []byte, what string) (time.Time, []byte, error) {
+	sec, n := binary.Varint(buf)
+	if n <= 0 {
+		return time.Time{}, nil, fmt.Errorf("%w: bad %s", ErrCorrupt, what)
+	}
+	nsec, buf, err := readUvarint(buf[n:], what)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if nsec >= 1e9 {
+		return time.Time{}, nil, fmt.Errorf("%w: bad %s", ErrCorrupt, what)
+	}
+	return time.Unix(sec, int64(nsec)), buf, nil
+}
+
+func readUvarint(buf []byte, what string) (uint64, []byte, error) {
+	v, n := binary.Uvarint(buf)
+	if n <= 0 {
+		return 0, nil, fmt.Errorf("%w: bad %s", ErrCorrupt, what)
+	}
+	return v, buf[n:], nil
+}
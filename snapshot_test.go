@@ -0,0 +1,81 @@
+package fixedarr_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+// snapshotV1 returns a version 1 snapshot, which has no sequence numbers,
+// of an at-capacity array of maxSize 2 holding 1 and 2.
+func snapshotV1() []byte {
+	data := []byte("FXAR\x01\x01\x02\x02\x011\x012")
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+}
+
+func TestSnapshotV1(t *testing.T) {
+	a := fixedarr.New(0)
+	if err := a.UnmarshalBinary(snapshotV1()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{1.0, 2.0}; !reflect.DeepEqual(a.Value(), want) || a.Max() != 2 {
+		t.Fatalf("Value() = %v, Max() = %d; want %v, 2", a.Value(), a.Max(), want)
+	}
+
+	// The elements get sequence numbers, and the array is saved as a
+	// version 2 snapshot keeping them.
+	h := a.PushHandle(3.0)
+	data, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[4] != 2 {
+		t.Fatalf("saved as version %d; want 2", data[4])
+	}
+	b := fixedarr.New(0)
+	if err := b.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := b.Get(h); !ok || v != 3.0 {
+		t.Fatalf("Get(h) = %v, %v; want 3, true", v, ok)
+	}
+	if want := []interface{}{2.0, 3.0}; !reflect.DeepEqual(b.Value(), want) {
+		t.Fatalf("Value() = %v; want %v", b.Value(), want)
+	}
+}
+
+func TestSnapshotCorrupt(t *testing.T) {
+	a := fixedarr.New(4)
+	a.PushMany("a", "b", "c")
+	data, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n < len(data); n++ {
+		if err := fixedarr.New(0).UnmarshalBinary(data[:n]); !errors.Is(err, fixedarr.ErrCorrupt) {
+			t.Fatalf("truncated to %d bytes: error = %v; want ErrCorrupt", n, err)
+		}
+	}
+	for i := range data {
+		damaged := append([]byte(nil), data...)
+		damaged[i] ^= 0x10
+		if err := fixedarr.New(0).UnmarshalBinary(damaged); err == nil {
+			t.Fatalf("byte %d damaged: no error", i)
+		}
+	}
+}
+
+func TestSnapshotMaxSizeOverflow(t *testing.T) {
+	data := []byte("FXAR\x02\x00")
+	data = binary.AppendUvarint(data, math.MaxInt+1)
+	data = append(data, 0, 0) // count, next
+	data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+	if err := fixedarr.New(0).UnmarshalBinary(data); !errors.Is(err, fixedarr.ErrCorrupt) {
+		t.Fatalf("error = %v; want ErrCorrupt", err)
+	}
+}
@@ -0,0 +1,151 @@
+package fixedarr
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Span is a completed trace span.
+type Span struct {
+	TraceID  string
+	SpanID   string
+	ParentID string // empty for root spans
+	Name     string
+	Start    time.Time
+	End      time.Time
+	Attrs    map[string]interface{}
+}
+
+// TraceNode is a span of a Trace, with its child spans ordered by Start.
+type TraceNode struct {
+	Span     Span
+	Children []*TraceNode
+}
+
+// Trace is the tree of the retained spans of a trace. Partial is set when
+// spans of the trace are known or suspected to be missing: the trace was
+// evicted before some of its spans arrived, or a span's parent is not
+// retained (such orphans are listed among the Roots).
+type Trace struct {
+	TraceID string
+	Roots   []*TraceNode
+	Partial bool
+}
+
+// SpanStore retains the most recent completed spans. When its oldest span
+// is evicted, the rest of that trace is evicted with it, so that retained
+// traces are whole; spans of an evicted trace that arrive later are kept,
+// but their trace is reported as partial.
+type SpanStore struct {
+	mu      *sync.Mutex
+	spans   *Array
+	evicted *Array // IDs of recently evicted traces
+	isEvict map[string]int
+}
+
+// NewSpanStore returns a new SpanStore retaining up to maxSpans spans;
+// maxSpans MUST be a positive number.
+func NewSpanStore(maxSpans int) *SpanStore {
+	if maxSpans < 1 {
+		panic("fixedarr.NewSpanStore: maxSpans must be positive")
+	}
+	return &SpanStore{
+		mu:      &sync.Mutex{},
+		spans:   New(maxSpans),
+		evicted: New(maxSpans),
+		isEvict: make(map[string]int),
+	}
+}
+
+// Add stores a completed span, evicting the oldest trace if needed.
+func (s *SpanStore) Add(span Span) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The oldest trace is evicted before span is pushed, so that span is
+	// kept even when it belongs to that trace.
+	a := s.spans
+	a.mu.Lock()
+	full := len(a.array) >= a.maxSize
+	var traceID string
+	if full {
+		traceID = a.array[0].(Span).TraceID
+		a.retain(func(el interface{}) bool {
+			return el.(Span).TraceID != traceID
+		})
+	}
+	a.push(span)
+	a.mu.Unlock()
+
+	if full {
+		s.markEvicted(traceID)
+	}
+}
+
+// markEvicted remembers that traceID was evicted; s.mu MUST be held.
+func (s *SpanStore) markEvicted(traceID string) {
+	s.evicted.mu.Lock()
+	old, ok := s.evicted.push(traceID)
+	s.evicted.mu.Unlock()
+
+	s.isEvict[traceID]++
+	if ok {
+		id := old.(string)
+		if s.isEvict[id]--; s.isEvict[id] == 0 {
+			delete(s.isEvict, id)
+		}
+	}
+}
+
+// Len returns the number of retained spans.
+func (s *SpanStore) Len() int {
+	return s.spans.Len()
+}
+
+// Trace assembles the retained spans of traceID into a tree; it returns
+// false if no span of the trace is retained.
+func (s *SpanStore) Trace(traceID string) (*Trace, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var nodes []*TraceNode
+	byID := make(map[string]*TraceNode)
+	s.spans.mu.RLock()
+	for _, el := range s.spans.array {
+		if span := el.(Span); span.TraceID == traceID {
+			n := &TraceNode{Span: span}
+			nodes = append(nodes, n)
+			byID[span.SpanID] = n
+		}
+	}
+	s.spans.mu.RUnlock()
+	if len(nodes) == 0 {
+		return nil, false
+	}
+
+	t := &Trace{TraceID: traceID, Partial: s.isEvict[traceID] > 0}
+	for _, n := range nodes {
+		parent, ok := byID[n.Span.ParentID]
+		switch {
+		case n.Span.ParentID == "":
+			t.Roots = append(t.Roots, n)
+		case ok:
+			parent.Children = append(parent.Children, n)
+		default:
+			t.Partial = true
+			t.Roots = append(t.Roots, n)
+		}
+	}
+	sortNodes(t.Roots)
+	return t, true
+}
+
+func sortNodes(nodes []*TraceNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].Span.Start.Before(nodes[j].Span.Start)
+	})
+	for _, n := range nodes {
+		sortNodes(n.Children)
+	}
+}
@@ -0,0 +1,78 @@
+package fixedarr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+func span(traceID, spanID, parentID string, start int) fixedarr.Span {
+	return fixedarr.Span{
+		TraceID:  traceID,
+		SpanID:   spanID,
+		ParentID: parentID,
+		Start:    time.Unix(int64(start), 0),
+		End:      time.Unix(int64(start)+1, 0),
+	}
+}
+
+func TestSpanStoreTrace(t *testing.T) {
+	s := fixedarr.NewSpanStore(10)
+	s.Add(span("A", "c2", "root", 3))
+	s.Add(span("A", "root", "", 1))
+	s.Add(span("B", "x", "", 1))
+	s.Add(span("A", "c1", "root", 2))
+	s.Add(span("A", "g", "c1", 4))
+
+	tr, ok := s.Trace("A")
+	if !ok || tr.Partial || len(tr.Roots) != 1 {
+		t.Fatalf("Trace(A) = %+v, %v", tr, ok)
+	}
+	root := tr.Roots[0]
+	if root.Span.SpanID != "root" || len(root.Children) != 2 ||
+		root.Children[0].Span.SpanID != "c1" || root.Children[1].Span.SpanID != "c2" ||
+		len(root.Children[0].Children) != 1 {
+		t.Fatalf("Trace(A) tree is wrong: %+v", root)
+	}
+	if _, ok := s.Trace("C"); ok {
+		t.Fatal("Trace(C) found an unknown trace")
+	}
+}
+
+func TestSpanStoreEvictsWholeTraces(t *testing.T) {
+	s := fixedarr.NewSpanStore(3)
+	s.Add(span("A", "a1", "", 1))
+	s.Add(span("B", "b1", "", 2))
+	s.Add(span("A", "a2", "a1", 3))
+	s.Add(span("C", "c1", "", 4)) // evicts a1 and a2
+
+	if s.Len() != 2 {
+		t.Fatalf("Len() = %d; want 2", s.Len())
+	}
+	if _, ok := s.Trace("A"); ok {
+		t.Fatal("Trace(A) is retained after eviction")
+	}
+
+	// A late span of the evicted trace is kept, but marked partial.
+	s.Add(span("A", "a3", "a1", 5))
+	tr, ok := s.Trace("A")
+	if !ok || !tr.Partial {
+		t.Fatalf("Trace(A) = %+v, %v; want a partial trace", tr, ok)
+	}
+}
+
+func TestSpanStoreTraceLongerThanStore(t *testing.T) {
+	s := fixedarr.NewSpanStore(2)
+	s.Add(span("A", "1", "", 1))
+	s.Add(span("A", "2", "1", 2))
+	s.Add(span("A", "3", "2", 3))
+
+	if s.Len() != 1 {
+		t.Fatalf("Len() = %d; want 1", s.Len())
+	}
+	tr, ok := s.Trace("A")
+	if !ok || !tr.Partial || len(tr.Roots) != 1 || tr.Roots[0].Span.SpanID != "3" {
+		t.Fatalf("Trace(A) = %+v, %v; want the newest span, partial", tr, ok)
+	}
+}
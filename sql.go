@@ -0,0 +1,43 @@
+package fixedarr
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// SQLArray wraps an Array so that it can be stored in a database column
+// (it implements driver.Valuer) and read back from one (it implements
+// sql.Scanner); the column holds the MarshalBinary encoding of the array.
+// Array already has a Value method, hence the wrapper:
+//
+//	db.Exec("UPDATE users SET recent = ? WHERE id = ?", fixedarr.SQLArray{arr}, id)
+//	db.QueryRow("SELECT recent FROM users WHERE id = ?", id).Scan(&fixedarr.SQLArray{arr})
+type SQLArray struct {
+	*Array
+}
+
+// Value returns the encoding of the array, or NULL for a nil Array.
+func (s SQLArray) Value() (driver.Value, error) {
+	if s.Array == nil {
+		return nil, nil
+	}
+	return s.Array.MarshalBinary()
+}
+
+// Scan decodes src into the array, which keeps its Codec; if the Array is
+// nil, a new one using DefaultCodec is allocated. NULL empties the array.
+func (s *SQLArray) Scan(src interface{}) error {
+	if s.Array == nil {
+		s.Array = New(0)
+	}
+	switch src := src.(type) {
+	case nil:
+		s.Array.Reset()
+		return nil
+	case []byte:
+		return s.Array.UnmarshalBinary(src)
+	case string:
+		return s.Array.UnmarshalBinary([]byte(src))
+	}
+	return fmt.Errorf("fixedarr: cannot scan %T into SQLArray", src)
+}
@@ -0,0 +1,160 @@
+package fixedarr_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+// fakeDriver is an in-process database/sql driver holding a single
+// key-value table; it understands two statements:
+//
+//	SET (key, value)
+//	GET (key), returning a single value column
+type fakeDriver struct {
+	mu    sync.Mutex
+	table map[string]driver.Value
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if query != "SET" && query != "GET" {
+		return nil, errors.New("fake: unknown statement " + query)
+	}
+	return fakeStmt{c.d, query}, nil
+}
+func (fakeConn) Close() error              { return nil }
+func (fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("fake: no transactions") }
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (fakeStmt) Close() error { return nil }
+
+func (s fakeStmt) NumInput() int {
+	if s.query == "SET" {
+		return 2
+	}
+	return 1
+}
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.query != "SET" {
+		return nil, errors.New("fake: Exec needs SET")
+	}
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	s.d.table[args[0].(string)] = args[1]
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.query != "GET" {
+		return nil, errors.New("fake: Query needs GET")
+	}
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	v, ok := s.d.table[args[0].(string)]
+	if !ok {
+		return &fakeRows{}, nil
+	}
+	return &fakeRows{values: []driver.Value{v}}, nil
+}
+
+type fakeRows struct{ values []driver.Value }
+
+func (*fakeRows) Columns() []string { return []string{"value"} }
+func (*fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+var fakeDB = &fakeDriver{table: map[string]driver.Value{}}
+
+func init() {
+	sql.Register("fixedarrfake", fakeDB)
+}
+
+func TestSQLArrayRoundTrip(t *testing.T) {
+	db, err := sql.Open("fixedarrfake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	a := fixedarr.New(3)
+	a.PushMany("a", "b", "c", "d")
+	if _, err := db.Exec("SET", "recent", fixedarr.SQLArray{Array: a}); err != nil {
+		t.Fatal(err)
+	}
+
+	var got fixedarr.SQLArray
+	if err := db.QueryRow("GET", "recent").Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"b", "c", "d"}; !reflect.DeepEqual(got.Array.Value(), want) || got.Max() != 3 {
+		t.Fatalf("scanned %v (max %d); want %v (max 3)", got.Array.Value(), got.Max(), want)
+	}
+
+	// The scanned array is at capacity, as the stored one was.
+	got.Push("e")
+	if want := []interface{}{"c", "d", "e"}; !reflect.DeepEqual(got.Array.Value(), want) {
+		t.Fatalf("after Push: %v; want %v", got.Array.Value(), want)
+	}
+}
+
+func TestSQLArrayNull(t *testing.T) {
+	db, err := sql.Open("fixedarrfake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("SET", "none", fixedarr.SQLArray{}); err != nil {
+		t.Fatal(err)
+	}
+	fakeDB.mu.Lock()
+	stored := fakeDB.table["none"]
+	fakeDB.mu.Unlock()
+	if stored != nil {
+		t.Fatalf("nil Array stored as %v; want NULL", stored)
+	}
+
+	a := fixedarr.New(2)
+	a.Push("stale")
+	got := fixedarr.SQLArray{Array: a}
+	if err := db.QueryRow("GET", "none").Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Array != a || got.Len() != 0 {
+		t.Fatalf("scanning NULL left %v", got.Array.Value())
+	}
+}
+
+func TestSQLArrayScanErrors(t *testing.T) {
+	var s fixedarr.SQLArray
+	if err := s.Scan(42); err == nil {
+		t.Fatal("Scan accepted an int64")
+	}
+	if err := s.Scan([]byte("not a snapshot")); !errors.Is(err, fixedarr.ErrCorrupt) {
+		t.Fatalf("Scan error = %v; want ErrCorrupt", err)
+	}
+}
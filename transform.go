@@ -0,0 +1,224 @@
+package fixedarr
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrAuth is returned (wrapped) when a sealed segment fails authentication,
+// because it was tampered with or the key is wrong.
+var ErrAuth = errors.New("fixedarr: segment authentication failed")
+
+// Transform is a reversible step applied to every persisted segment, such as
+// compression or encryption. header holds the segment header, which lists
+// the IDs of the whole chain; authenticating transforms MUST bind it to
+// their output (e.g. as additional authenticated data).
+type Transform interface {
+	// ID identifies the transform in segment headers; IDs below 128 are
+	// reserved for this package.
+	ID() byte
+	Apply(data, header []byte) ([]byte, error)
+	Revert(data, header []byte) ([]byte, error)
+}
+
+// WithTransforms sets the chain of transforms applied, in order, to the
+// segments persisted by MarshalBinary (and so by Checkpointer and SQLArray);
+// they are reverted in reverse order when loading. Once set, unsealed
+// snapshots are refused, so that encryption cannot be bypassed.
+func WithTransforms(ts ...Transform) Option {
+	return func(a *Array) {
+		a.transforms = ts
+	}
+}
+
+// Segment layout:
+//
+//	magic "FXSG" | version | n | n * transform ID | payload
+const (
+	segmentMagic   = "FXSG"
+	segmentVersion = 1
+)
+
+func isSegment(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(segmentMagic))
+}
+
+// sealSegment applies ts to data and prefixes the result with its header.
+func sealSegment(data []byte, ts []Transform) ([]byte, error) {
+	header := []byte(segmentMagic)
+	header = append(header, segmentVersion, byte(len(ts)))
+	for _, t := range ts {
+		header = append(header, t.ID())
+	}
+	for _, t := range ts {
+		var err error
+		if data, err = t.Apply(data, header); err != nil {
+			return nil, fmt.Errorf("fixedarr: applying transform %d: %w", t.ID(), err)
+		}
+	}
+	return append(header, data...), nil
+}
+
+// openSegment checks that the header of seg lists ts, and reverts them.
+func openSegment(seg []byte, ts []Transform) ([]byte, error) {
+	if len(seg) < len(segmentMagic)+2 {
+		return nil, fmt.Errorf("%w: truncated segment", ErrCorrupt)
+	}
+	if v := seg[len(segmentMagic)]; v != segmentVersion {
+		return nil, fmt.Errorf("fixedarr: unsupported segment version %d", v)
+	}
+	n := int(seg[len(segmentMagic)+1])
+	headerLen := len(segmentMagic) + 2 + n
+	if len(seg) < headerLen {
+		return nil, fmt.Errorf("%w: truncated segment header", ErrCorrupt)
+	}
+	header, data := seg[:headerLen], seg[headerLen:]
+	ids := header[len(segmentMagic)+2:]
+	if len(ids) != len(ts) {
+		return nil, fmt.Errorf("fixedarr: segment has transforms %v, %d configured", ids, len(ts))
+	}
+	for i, t := range ts {
+		if ids[i] != t.ID() {
+			return nil, fmt.Errorf("fixedarr: segment has transforms %v, configured transform %d is %d", ids, i, t.ID())
+		}
+	}
+	for i := len(ts) - 1; i >= 0; i-- {
+		var err error
+		if data, err = ts[i].Revert(data, header); err != nil {
+			return nil, fmt.Errorf("fixedarr: reverting transform %d: %w", ts[i].ID(), err)
+		}
+	}
+	return data, nil
+}
+
+const (
+	gzipTransformID   = 1
+	flateTransformID  = 2
+	aesGCMTransformID = 3
+)
+
+// Gzip returns a Transform compressing segments with gzip at the provided
+// level (see compress/gzip). Segments decompressing to more than 1 GiB are
+// refused as corrupt.
+func Gzip(level int) Transform {
+	return gzipTransform{level}
+}
+
+type gzipTransform struct{ level int }
+
+func (gzipTransform) ID() byte { return gzipTransformID }
+
+func (t gzipTransform) Apply(data, _ []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, t.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipTransform) Revert(data, _ []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	return decompress(r)
+}
+
+// Flate returns a Transform compressing segments with DEFLATE at the
+// provided level (see compress/flate). As for Gzip, segments decompressing
+// to more than 1 GiB are refused as corrupt.
+func Flate(level int) Transform {
+	return flateTransform{level}
+}
+
+type flateTransform struct{ level int }
+
+func (flateTransform) ID() byte { return flateTransformID }
+
+func (t flateTransform) Apply(data, _ []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, t.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (flateTransform) Revert(data, _ []byte) ([]byte, error) {
+	return decompress(flate.NewReader(bytes.NewReader(data)))
+}
+
+// maxDecompressed is the largest segment the compressing transforms
+// decompress, so that a small crafted segment cannot exhaust memory.
+const maxDecompressed = 1 << 30
+
+// decompress reads r, which MUST NOT yield more than maxDecompressed bytes.
+func decompress(r io.Reader) ([]byte, error) {
+	out, err := io.ReadAll(io.LimitReader(r, maxDecompressed+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if len(out) > maxDecompressed {
+		return nil, fmt.Errorf("%w: segment decompresses to more than %d bytes", ErrCorrupt, maxDecompressed)
+	}
+	return out, nil
+}
+
+// AESGCM returns a Transform encrypting and authenticating segments, and
+// their header, with AES-GCM; key MUST be 16, 24 or 32 bytes long. Every
+// segment gets a fresh random nonce.
+func AESGCM(key []byte) (Transform, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return aesGCMTransform{aead}, nil
+}
+
+type aesGCMTransform struct{ aead cipher.AEAD }
+
+func (aesGCMTransform) ID() byte { return aesGCMTransformID }
+
+func (t aesGCMTransform) Apply(data, header []byte) ([]byte, error) {
+	nonce := make([]byte, t.aead.NonceSize(), t.aead.NonceSize()+len(data)+t.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return t.aead.Seal(nonce, nonce, data, header), nil
+}
+
+func (t aesGCMTransform) Revert(data, header []byte) ([]byte, error) {
+	if len(data) < t.aead.NonceSize() {
+		return nil, ErrAuth
+	}
+	nonce, ciphertext := data[:t.aead.NonceSize()], data[t.aead.NonceSize():]
+	out, err := t.aead.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return nil, ErrAuth
+	}
+	return out, nil
+}
@@ -0,0 +1,102 @@
+package fixedarr_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+func aesGCM(t *testing.T, key byte) fixedarr.Transform {
+	t.Helper()
+	tr, err := fixedarr.AESGCM(bytes.Repeat([]byte{key}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tr
+}
+
+// sealed returns a snapshot of an array holding 1, 2 and 3, sealed with ts.
+func sealed(t *testing.T, ts ...fixedarr.Transform) []byte {
+	t.Helper()
+	a := fixedarr.New(3, fixedarr.WithTransforms(ts...))
+	a.PushMany(1.0, 2.0, 3.0)
+	data, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestTransformsRoundTrip(t *testing.T) {
+	for name, ts := range map[string][]fixedarr.Transform{
+		"gzip":       {fixedarr.Gzip(gzip.BestCompression)},
+		"flate":      {fixedarr.Flate(flate.BestSpeed)},
+		"aes-gcm":    {aesGCM(t, 1)},
+		"gzip+aes":   {fixedarr.Gzip(gzip.DefaultCompression), aesGCM(t, 1)},
+		"flate+gzip": {fixedarr.Flate(flate.DefaultCompression), fixedarr.Gzip(gzip.DefaultCompression)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			b := fixedarr.New(0, fixedarr.WithTransforms(ts...))
+			if err := b.UnmarshalBinary(sealed(t, ts...)); err != nil {
+				t.Fatal(err)
+			}
+			if want := []interface{}{1.0, 2.0, 3.0}; !reflect.DeepEqual(b.Value(), want) {
+				t.Fatalf("Value() = %v; want %v", b.Value(), want)
+			}
+		})
+	}
+}
+
+func TestTransformsWrongKey(t *testing.T) {
+	data := sealed(t, fixedarr.Gzip(gzip.DefaultCompression), aesGCM(t, 1))
+	b := fixedarr.New(0, fixedarr.WithTransforms(fixedarr.Gzip(gzip.DefaultCompression), aesGCM(t, 2)))
+	if err := b.UnmarshalBinary(data); !errors.Is(err, fixedarr.ErrAuth) {
+		t.Fatalf("error = %v; want ErrAuth", err)
+	}
+	if b.Len() != 0 {
+		t.Fatalf("Len() = %d after a failed UnmarshalBinary; want 0", b.Len())
+	}
+}
+
+func TestTransformsMismatch(t *testing.T) {
+	data := sealed(t, fixedarr.Gzip(gzip.DefaultCompression))
+	for name, ts := range map[string][]fixedarr.Transform{
+		"other":    {fixedarr.Flate(flate.DefaultCompression)},
+		"more":     {fixedarr.Gzip(gzip.DefaultCompression), aesGCM(t, 1)},
+		"reversed": {aesGCM(t, 1), fixedarr.Gzip(gzip.DefaultCompression)},
+	} {
+		b := fixedarr.New(0, fixedarr.WithTransforms(ts...))
+		if err := b.UnmarshalBinary(data); err == nil {
+			t.Fatalf("%s: UnmarshalBinary of a segment with other transforms succeeded", name)
+		}
+	}
+
+	// A sealed snapshot needs transforms, and an unsealed one is refused
+	// once transforms are configured.
+	if err := fixedarr.New(0).UnmarshalBinary(data); err == nil {
+		t.Fatal("UnmarshalBinary of a sealed snapshot without transforms succeeded")
+	}
+	b := fixedarr.New(0, fixedarr.WithTransforms(aesGCM(t, 1)))
+	if err := b.UnmarshalBinary(sealed(t)); err == nil {
+		t.Fatal("UnmarshalBinary of an unsealed snapshot succeeded")
+	}
+}
+
+func TestTransformsCorrupt(t *testing.T) {
+	for name, tr := range map[string]fixedarr.Transform{
+		"gzip":  fixedarr.Gzip(gzip.DefaultCompression),
+		"flate": fixedarr.Flate(flate.DefaultCompression),
+	} {
+		data := sealed(t, tr)
+		data = data[:len(data)-4]
+		b := fixedarr.New(0, fixedarr.WithTransforms(tr))
+		if err := b.UnmarshalBinary(data); !errors.Is(err, fixedarr.ErrCorrupt) {
+			t.Fatalf("%s: truncated segment error = %v; want ErrCorrupt", name, err)
+		}
+	}
+}
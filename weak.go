@@ -0,0 +1,77 @@
+package fixedarr
+
+import "weak"
+
+// WeakArray is a fixed size array holding weak pointers: the elements stay
+// in order, but the garbage collector may reclaim any of them once nothing
+// else references it. Value skips the collected elements, and Compact
+// reclaims their slots; a Push to a full array compacts it first, so a live
+// element is only evicted when no slot can be reclaimed.
+type WeakArray[T any] struct {
+	arr *Array
+}
+
+// NewWeakArray returns a new WeakArray holding up to maxSize elements.
+func NewWeakArray[T any](maxSize int) *WeakArray[T] {
+	return &WeakArray[T]{arr: New(maxSize)}
+}
+
+// Push pushes a weak pointer to p.
+func (w *WeakArray[T]) Push(p *T) {
+	a := w.arr
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.array) >= a.maxSize {
+		w.compact()
+	}
+	a.push(weak.Make(p))
+}
+
+// Value returns the elements not yet collected, oldest first.
+func (w *WeakArray[T]) Value() []*T {
+	a := w.arr
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	value := make([]*T, 0, len(a.array))
+	for _, el := range a.array {
+		if p := el.(weak.Pointer[T]).Value(); p != nil {
+			value = append(value, p)
+		}
+	}
+	return value
+}
+
+// Compact removes the collected elements, and returns how many it removed.
+func (w *WeakArray[T]) Compact() int {
+	a := w.arr
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return w.compact()
+}
+
+// compact removes the collected elements; the lock of the array MUST be held.
+func (w *WeakArray[T]) compact() int {
+	removed := w.arr.retain(func(el interface{}) bool {
+		return el.(weak.Pointer[T]).Value() != nil
+	})
+	return len(removed)
+}
+
+// Len returns the number of slots in use, including those of collected
+// elements not yet compacted.
+func (w *WeakArray[T]) Len() int {
+	return w.arr.Len()
+}
+
+// Max returns the max size of the array.
+func (w *WeakArray[T]) Max() int {
+	return w.arr.Max()
+}
+
+// Reset empties the array.
+func (w *WeakArray[T]) Reset() {
+	w.arr.Reset()
+}
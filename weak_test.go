@@ -0,0 +1,68 @@
+package fixedarr_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/gagliardetto/fixedarr"
+)
+
+// blob is large enough to be allocated on its own, so that it is collected
+// as soon as it is unreachable.
+type blob [1 << 16]byte
+
+func TestWeakArrayCompact(t *testing.T) {
+	w := fixedarr.NewWeakArray[blob](4)
+	keep := []*blob{new(blob), new(blob)}
+	w.Push(keep[0])
+	w.Push(new(blob))
+	w.Push(keep[1])
+	w.Push(new(blob))
+	runtime.GC()
+
+	if v := w.Value(); len(v) != 2 || v[0] != keep[0] || v[1] != keep[1] {
+		t.Fatalf("Value() = %v; want the two live elements in order", v)
+	}
+	if w.Len() != 4 {
+		t.Fatalf("Len() = %d before Compact; want 4", w.Len())
+	}
+	if n := w.Compact(); n != 2 {
+		t.Fatalf("Compact() = %d; want 2", n)
+	}
+	if w.Len() != 2 || w.Compact() != 0 {
+		t.Fatalf("Len() = %d after Compact; want 2", w.Len())
+	}
+	runtime.KeepAlive(keep)
+}
+
+func TestWeakArrayPushCompactsWhenFull(t *testing.T) {
+	w := fixedarr.NewWeakArray[blob](3)
+	keep := []*blob{new(blob), new(blob), new(blob)}
+	w.Push(keep[0])
+	w.Push(new(blob))
+	w.Push(keep[1])
+	runtime.GC()
+
+	// The collected slot is reclaimed instead of evicting keep[0].
+	w.Push(keep[2])
+	if v := w.Value(); len(v) != 3 || v[0] != keep[0] || v[2] != keep[2] {
+		t.Fatalf("Value() = %v; want keep[0], keep[1], keep[2]", v)
+	}
+
+	// With every element live, the oldest is evicted as usual.
+	last := new(blob)
+	w.Push(last)
+	if v := w.Value(); len(v) != 3 || v[0] != keep[1] || v[2] != last {
+		t.Fatalf("Value() = %v; want keep[1], keep[2], last", v)
+	}
+	if w.Max() != 3 {
+		t.Fatalf("Max() = %d; want 3", w.Max())
+	}
+	runtime.KeepAlive(keep)
+	runtime.KeepAlive(last)
+
+	w.Reset()
+	if w.Len() != 0 {
+		t.Fatalf("Len() = %d after Reset", w.Len())
+	}
+}